@@ -0,0 +1,257 @@
+// Package backup runs scheduled database backups declared in spec.backup of
+// PostgreSQLConfig objects. Dumps are streamed to the destination URL with an
+// HTTP PUT, so any blob store with a plain HTTP frontend works as a
+// destination.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/logging"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+)
+
+// Config is the backup controller configuration.
+type Config struct {
+	// CheckInterval is how often due backups are checked for. Defaults to
+	// one minute.
+	CheckInterval time.Duration
+	// ListConfigs returns the current PostgreSQLConfig objects.
+	ListConfigs func() ([]containerconfv1.PostgreSQLConfig, error)
+	// ReadSecret reads the destination Secret when the destination is
+	// declared as a Secret reference.
+	ReadSecret customobject.SecretReader
+	// Resource provides database access for dumping.
+	Resource *customobject.Resource
+	// RecordBackup optionally persists the last backup time in the object
+	// status.
+	RecordBackup func(obj *containerconfv1.PostgreSQLConfig, t time.Time)
+}
+
+// Controller periodically checks the objects for due backups and performs
+// them.
+type Controller struct {
+	checkInterval time.Duration
+	listConfigs   func() ([]containerconfv1.PostgreSQLConfig, error)
+	readSecret    customobject.SecretReader
+	resource      *customobject.Resource
+	recordBackup  func(obj *containerconfv1.PostgreSQLConfig, t time.Time)
+
+	// mutex guards lastBackup and uploaded keyed by namespace/name.
+	mutex      sync.Mutex
+	lastBackup map[string]time.Time
+	uploaded   map[string][]string
+}
+
+func New(config Config) *Controller {
+	checkInterval := config.CheckInterval
+	if checkInterval == 0 {
+		checkInterval = time.Minute
+	}
+
+	return &Controller{
+		checkInterval: checkInterval,
+		listConfigs:   config.ListConfigs,
+		readSecret:    config.ReadSecret,
+		resource:      config.Resource,
+		recordBackup:  config.RecordBackup,
+
+		lastBackup: map[string]time.Time{},
+		uploaded:   map[string][]string{},
+	}
+}
+
+// Run checks for due backups until the context is cancelled. It blocks.
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.backupDue(ctx)
+		}
+	}
+}
+
+// backupDue performs a backup of every object whose schedule elapsed since
+// its last backup.
+func (c *Controller) backupDue(ctx context.Context) {
+	objs, err := c.listConfigs()
+	if err != nil {
+		logging.Errorf("backing up: listing objects: %s", err)
+		return
+	}
+
+	for i := range objs {
+		obj := &objs[i]
+
+		if obj.Spec.Backup == nil {
+			continue
+		}
+		if customobject.IsPaused(obj.Annotations) {
+			continue
+		}
+
+		schedule, err := time.ParseDuration(obj.Spec.Backup.Schedule)
+		if err != nil {
+			logging.Errorf("backing up %s/%s: parsing schedule: %s", obj.Namespace, obj.Name, err)
+			continue
+		}
+
+		key := obj.Namespace + "/" + obj.Name
+		if time.Since(c.lastBackupTime(key, obj)) < schedule {
+			continue
+		}
+
+		now := time.Now().UTC()
+
+		err = c.backup(ctx, obj, now)
+		if err != nil {
+			logging.Errorf("backing up %s/%s: %s", obj.Namespace, obj.Name, err)
+			continue
+		}
+
+		logging.Infof("backing up %s/%s: backup created", obj.Namespace, obj.Name)
+
+		c.mutex.Lock()
+		c.lastBackup[key] = now
+		c.mutex.Unlock()
+
+		if c.recordBackup != nil {
+			c.recordBackup(obj, now)
+		}
+	}
+}
+
+// lastBackupTime returns the last backup time known for the object. The
+// in-memory record takes precedence, the status recorded time covers
+// operator restarts.
+func (c *Controller) lastBackupTime(key string, obj *containerconfv1.PostgreSQLConfig) time.Time {
+	c.mutex.Lock()
+	last, ok := c.lastBackup[key]
+	c.mutex.Unlock()
+	if ok {
+		return last
+	}
+
+	if obj.Status.LastBackupTime != "" {
+		last, err := time.Parse(time.RFC3339, obj.Status.LastBackupTime)
+		if err == nil {
+			return last
+		}
+	}
+
+	return time.Time{}
+}
+
+// backup streams a dump of the object's database to the destination.
+func (c *Controller) backup(ctx context.Context, obj *containerconfv1.PostgreSQLConfig, now time.Time) error {
+	url, err := c.destinationURL(obj.Spec.Backup)
+	if err != nil {
+		return err
+	}
+
+	// The status records the actual database name when the operator
+	// derives it, e.g. with namespace prefixing.
+	dumped := obj.PostgreSQLConfig
+	if obj.Status.DatabaseName != "" {
+		dumped.Spec.Database = obj.Status.DatabaseName
+	}
+
+	target := fmt.Sprintf("%s/%s-%s.sql", strings.TrimSuffix(url, "/"), dumped.Spec.Database, now.Format("20060102T150405"))
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.resource.DumpDatabase(ctx, &dumped, pw))
+	}()
+
+	req, err := http.NewRequest(http.MethodPut, target, pr)
+	if err != nil {
+		return fmt.Errorf("creating upload request: %s", err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading dump: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("uploading dump: bad status status=%d", res.StatusCode)
+	}
+
+	c.enforceRetention(obj.Namespace+"/"+obj.Name, target, obj.Spec.Backup.Retention)
+
+	return nil
+}
+
+// destinationURL resolves the backup destination declared either inline or
+// via a Secret reference.
+func (c *Controller) destinationURL(backup *customobject.BackupSpec) (string, error) {
+	if backup.DestinationURL != "" {
+		return backup.DestinationURL, nil
+	}
+
+	if backup.DestinationSecretRef == nil {
+		return "", fmt.Errorf("resolving destination: no destination declared")
+	}
+	if c.readSecret == nil {
+		return "", fmt.Errorf("resolving destination: no secret reader configured")
+	}
+
+	data, err := c.readSecret(*backup.DestinationSecretRef)
+	if err != nil {
+		return "", fmt.Errorf("resolving destination: %s", err)
+	}
+
+	url, ok := data["url"]
+	if !ok {
+		return "", fmt.Errorf("resolving destination: secret name=%#q misses key=%#q", backup.DestinationSecretRef.Name, "url")
+	}
+
+	return string(url), nil
+}
+
+// enforceRetention deletes the oldest uploaded backups beyond the retention
+// count. Only backups uploaded by this process are tracked, deletion is best
+// effort.
+func (c *Controller) enforceRetention(key, target string, retention int) {
+	if retention <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	c.uploaded[key] = append(c.uploaded[key], target)
+	var expired []string
+	if len(c.uploaded[key]) > retention {
+		n := len(c.uploaded[key]) - retention
+		expired = c.uploaded[key][:n]
+		c.uploaded[key] = c.uploaded[key][n:]
+	}
+	c.mutex.Unlock()
+
+	for _, url := range expired {
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		if err != nil {
+			logging.Errorf("backing up: deleting expired backup url=%#q: %s", url, err)
+			continue
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			logging.Errorf("backing up: deleting expired backup url=%#q: %s", url, err)
+			continue
+		}
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,28 @@
+// Package buildinfo holds build metadata stamped at link time, e.g.
+//
+//	go build -ldflags "\
+//	  -X github.com/giantswarm/operator-workshop/buildinfo.Version=$(git describe --tags) \
+//	  -X github.com/giantswarm/operator-workshop/buildinfo.GitCommit=$(git rev-parse HEAD) \
+//	  -X github.com/giantswarm/operator-workshop/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Unstamped binaries report the dev defaults.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var (
+	// Version is the released version, e.g. a git tag.
+	Version = "dev"
+	// GitCommit is the git commit the binary was built from.
+	GitCommit = "unknown"
+	// BuildDate is the RFC3339 time the binary was built.
+	BuildDate = "unknown"
+)
+
+// String formats the build metadata on one line.
+func String() string {
+	return fmt.Sprintf("version %s, commit %s, built %s, %s", Version, GitCommit, BuildDate, runtime.Version())
+}
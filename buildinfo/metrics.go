@@ -0,0 +1,24 @@
+package buildinfo
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// buildInfo follows the standard Prometheus build information pattern: a
+// gauge that is always 1, carrying the build metadata as labels. Joining on
+// it answers which build serves which metrics.
+var buildInfo = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "operator_workshop",
+		Name:      "build_info",
+		Help:      "Build metadata of the running binary, value is always 1.",
+	},
+	[]string{"version", "commit", "builddate", "goversion"},
+)
+
+func init() {
+	buildInfo.WithLabelValues(Version, GitCommit, BuildDate, runtime.Version()).Set(1)
+	prometheus.MustRegister(buildInfo)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/mysqlops"
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Verify connectivity and configuration and exit.",
+		Long: `Verify connectivity and configuration and exit.
+
+The command checks Kubernetes connectivity, existence of the custom
+resource definitions, and database connectivity and credentials. The run
+subcommand performs the same checks at startup before entering the
+reconcile loop.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := resolveDatabase()
+			if err != nil {
+				return err
+			}
+			return runChecks(true)
+		},
+	}
+
+	addDatabaseFlags(cmd.Flags())
+
+	return cmd
+}
+
+// runChecks fails fast on configuration problems with errors saying what to
+// fix. With strict set missing custom resource definitions are an error,
+// the run subcommand tolerates them because the solutions create the CRDs
+// at startup.
+func runChecks(strict bool) error {
+	restConfig, err := newRestConfig()
+	if err != nil {
+		return err
+	}
+
+	// Kubernetes connectivity.
+	k8sClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating K8s client: %s", err)
+	}
+	version, err := k8sClient.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("checking Kubernetes API %#q: %s (check the kubernetes.server, kubernetes.crt, kubernetes.key and kubernetes.ca flags)", f.K8sServer, err)
+	}
+	logging.Infof("check kubernetes: ok version=%s", version.GitVersion)
+
+	// Custom resource definitions.
+	k8sExtClient, err := apiextensionsclient.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating K8s apiextensions client: %s", err)
+	}
+	crds := []string{"postgresqlconfigs.containerconf.de"}
+	if f.MySQLHost != "" {
+		crds = append(crds, "mysqlconfigs.containerconf.de")
+	}
+	for _, name := range crds {
+		_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, apismetav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if strict {
+				return fmt.Errorf("checking custom resource %#q: not found (run \"operator install-crd\")", name)
+			}
+			logging.Warnf("check custom resource name=%s: not found, created at startup", name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("checking custom resource %#q: %s", name, err)
+		}
+		logging.Infof("check custom resource name=%s: ok", name)
+	}
+
+	// PostgreSQL connectivity and credentials.
+	{
+		ops, err := postgresqlops.New(postgresqlops.Config{
+			Host:         f.DBHost,
+			Port:         f.DBPort,
+			User:         f.DBUser,
+			Password:     f.DBPassword,
+			PasswordFile: f.DBPasswordFile,
+		})
+		if err != nil {
+			return fmt.Errorf("creating PostgreSQLOps: %s", err)
+		}
+		defer ops.Close()
+
+		err = ops.Ping(context.Background())
+		if err != nil {
+			return fmt.Errorf("checking postgresql %s:%d: %s (check the postgresql.host, postgresql.port, postgresql.user and postgresql.password flags)", f.DBHost, f.DBPort, err)
+		}
+		logging.Infof("check postgresql: ok")
+
+		err = ops.CheckCompatibility()
+		if err != nil {
+			return fmt.Errorf("checking postgresql version: %s", err)
+		}
+		logging.Infof("check postgresql version: ok")
+	}
+
+	// MySQL connectivity and credentials when a MySQL server is
+	// configured.
+	if f.MySQLHost != "" {
+		ops, err := mysqlops.New(mysqlops.Config{
+			Host:     f.MySQLHost,
+			Port:     f.MySQLPort,
+			User:     f.MySQLUser,
+			Password: f.MySQLPassword,
+		})
+		if err != nil {
+			return fmt.Errorf("creating MySQLOps: %s", err)
+		}
+		defer ops.Close()
+
+		_, err = ops.ListDatabases()
+		if err != nil {
+			return fmt.Errorf("checking mysql %s:%d: %s (check the mysql.host, mysql.port, mysql.user and mysql.password flags)", f.MySQLHost, f.MySQLPort, err)
+		}
+		logging.Infof("check mysql: ok")
+	}
+
+	return nil
+}
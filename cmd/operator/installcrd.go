@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/operator-workshop/crdutil"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/spf13/cobra"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newInstallCRDCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install-crd",
+		Short: "Create or update the custom resource definitions and exit.",
+		Long: `Create or update the custom resource definitions and exit.
+
+The operators create the CRDs at startup themselves. Separating the CRD
+management into its own command covers pipelines deploying CRDs with
+elevated permissions while the operator deployment runs with a namespaced
+role.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return installCRDs()
+		},
+	}
+}
+
+func installCRDs() error {
+	restConfig, err := newRestConfig()
+	if err != nil {
+		return err
+	}
+
+	k8sExtClient, err := apiextensionsclient.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating K8s apiextensions client: %s", err)
+	}
+
+	crds := []*apiextensionsv1beta1.CustomResourceDefinition{
+		crdutil.NewCRD("postgresqlconfigs", "postgresqlconfig", "PostgreSQLConfig"),
+		crdutil.NewCRD("postgresqlservers", "postgresqlserver", "PostgreSQLServer"),
+		crdutil.NewCRD("mysqlconfigs", "mysqlconfig", "MySQLConfig"),
+	}
+	for _, crd := range crds {
+		_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
+		if apierrors.IsAlreadyExists(err) {
+			err := updateCRD(k8sExtClient, crd)
+			if err != nil {
+				return fmt.Errorf("updating custom resource name=%s: %s", crd.Name, err)
+			}
+			logging.Infof("custom resource name=%s: updated", crd.Name)
+		} else if err != nil {
+			return fmt.Errorf("creating custom resource name=%s: %s", crd.Name, err)
+		} else {
+			logging.Infof("custom resource name=%s: created", crd.Name)
+		}
+
+		// Install kubectl get columns. Best effort, apiservers predating
+		// additionalPrinterColumns drop them.
+		columns := crdutil.ConfigColumns
+		if crd.Spec.Names.Kind == "PostgreSQLServer" {
+			columns = crdutil.ServerColumns
+		}
+		err = crdutil.EnsurePrinterColumns(k8sExtClient.Apiextensions().RESTClient(), crd.Name, columns)
+		if err != nil {
+			logging.Warnf("installing printer columns name=%s: %s", crd.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// updateCRD replaces the spec of the existing CRD, retrying on conflicts.
+func updateCRD(k8sExtClient *apiextensionsclient.Clientset, crd *apiextensionsv1beta1.CustomResourceDefinition) error {
+	for {
+		existing, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Get(crd.Name, apismetav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("getting custom resource: %s", err)
+		}
+
+		existing.Spec = crd.Spec
+
+		_, err = k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Update(existing)
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		return err
+	}
+}
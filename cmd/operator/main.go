@@ -0,0 +1,206 @@
+// Command operator is the unified CLI for the workshop solutions. The run
+// subcommand runs any solution behind a --solution flag, sharing flag
+// parsing, logging setup and signal handling instead of the divergent
+// per-solution main packages. The install-crd subcommand only manages the
+// custom resource definitions, separating CRD management from operator
+// deployment, e.g. in GitOps pipelines.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/buildinfo"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	dbServiceDefault = "workshop-postgresql"
+	dbUserDefault    = "postgres"
+)
+
+// flags is the superset of the flags of all solutions. The kubernetes
+// connection and logging flags are persistent, the install-crd subcommand
+// uses them too.
+type flags struct {
+	Solution string
+
+	DBHost         string
+	DBPort         int
+	DBUser         string
+	DBPassword     string
+	DBPasswordFile string
+
+	DBService          string
+	DBServiceNamespace string
+	DBDiscoveryMode    string
+
+	MySQLHost     string
+	MySQLPort     int
+	MySQLUser     string
+	MySQLPassword string
+
+	NamespacePrefix      bool
+	EnableOrphanDeletion bool
+
+	K8sInCluster   bool
+	K8sServer      string
+	K8sCrtFile     string
+	K8sKeyFile     string
+	K8sCAFile      string
+	K8sToken       string
+	K8sTokenFile   string
+	K8sExecCommand string
+	K8sQPS         float64
+	K8sBurst       int
+	Namespace      string
+	LabelSelector  string
+	ResyncPeriod   time.Duration
+	RateWait       time.Duration
+	SharedInformer bool
+
+	HTTPRequestTimeout      time.Duration
+	HTTPDialTimeout         time.Duration
+	HTTPTLSHandshakeTimeout time.Duration
+	HTTPMaxIdleConns        int
+
+	Workers int
+	Debug   bool
+	DryRun  bool
+
+	WatchUnhealthyAfter time.Duration
+	HealthAddr          string
+	MetricsAddr         string
+
+	LeaderElect              bool
+	LeaderElectIdentity      string
+	LeaderElectLeaseDuration time.Duration
+
+	SlowReconcileThreshold time.Duration
+	TracingEndpoint        string
+	GracefulTimeout        time.Duration
+
+	LogFormat string
+	LogLevel  string
+
+	ShowVersion bool
+}
+
+// f holds the parsed flag values shared between the subcommands.
+var f flags
+
+// defaults are flag defaults derived from the environment, e.g. the
+// current kubeconfig context.
+type defaults struct {
+	homeDir       string
+	hostname      string
+	serverDefault string
+}
+
+func main() {
+	err := newRootCommand().Execute()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	d := detectDefaults()
+
+	root := &cobra.Command{
+		Use:           "operator",
+		Short:         "Run the workshop database operators.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if f.ShowVersion {
+				fmt.Println(buildinfo.String())
+				os.Exit(0)
+			}
+			return logging.Configure(logging.Config{Format: f.LogFormat, Level: f.LogLevel})
+		},
+	}
+
+	pf := root.PersistentFlags()
+	pf.BoolVar(&f.ShowVersion, "version", false, "Print the build information and exit.")
+	pf.StringVar(&f.LogFormat, "log-format", logging.FormatText, "Log output format, \"text\" or \"json\".")
+	pf.StringVar(&f.LogLevel, "log-level", "info", "Minimum level logged, one of \"debug\", \"info\", \"warn\" and \"error\".")
+	pf.BoolVar(&f.K8sInCluster, "kubernetes.incluster", false, "Run inside Kubernets cluster.")
+	pf.StringVar(&f.K8sServer, "kubernetes.server", d.serverDefault, "Kubernetes API server address.")
+	pf.StringVar(&f.K8sCrtFile, "kubernetes.crt", path.Join(d.homeDir, ".minikube/apiserver.crt"), "Kubernetes certificate file path.")
+	pf.StringVar(&f.K8sKeyFile, "kubernetes.key", path.Join(d.homeDir, ".minikube/apiserver.key"), "Kubernetes key file path.")
+	pf.StringVar(&f.K8sCAFile, "kubernetes.ca", path.Join(d.homeDir, ".minikube/ca.crt"), "Kubernetes CA file path.")
+	pf.Float64Var(&f.K8sQPS, "kubernetes.qps", 0, "Maximum queries per second against the Kubernetes API. 0 uses the client default.")
+	pf.IntVar(&f.K8sBurst, "kubernetes.burst", 0, "Maximum burst of queries against the Kubernetes API.")
+	pf.StringVar(&f.Namespace, "kubernetes.namespace", "", "Namespace to watch. Empty watches all namespaces.")
+	pf.StringVar(&f.LabelSelector, "kubernetes.selector", "", "Label selector filtering watched objects.")
+
+	root.AddCommand(newRunCommand(d))
+	root.AddCommand(newPlanCommand())
+	root.AddCommand(newCheckCommand())
+	root.AddCommand(newInstallCRDCommand())
+	root.AddCommand(newVersionCommand())
+
+	return root
+}
+
+// newRestConfig builds the Kubernetes client configuration from the
+// persistent connection flags.
+func newRestConfig() (*rest.Config, error) {
+	if f.K8sInCluster {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("creating incluster config: %s", err)
+		}
+		return restConfig, nil
+	}
+
+	return &rest.Config{
+		Host: f.K8sServer,
+		TLSClientConfig: rest.TLSClientConfig{
+			CertFile: f.K8sCrtFile,
+			KeyFile:  f.K8sKeyFile,
+			CAFile:   f.K8sCAFile,
+		},
+	}, nil
+}
+
+// detectDefaults derives flag defaults from the environment. Failures leave
+// zero defaults, the flags can always be set explicitly.
+func detectDefaults() defaults {
+	var d defaults
+
+	{
+		u, err := user.Current()
+		if err != nil {
+			d.homeDir = os.Getenv("HOME")
+		} else {
+			d.homeDir = u.HomeDir
+		}
+
+	}
+
+	d.hostname, _ = os.Hostname()
+
+	{
+		kubeconfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+		if err == nil {
+			context, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]
+			if ok {
+				cluster, ok := kubeconfig.Clusters[context.Cluster]
+				if ok {
+					d.serverDefault = cluster.Server
+				}
+			}
+		}
+	}
+
+	return d
+}
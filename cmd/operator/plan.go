@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/mysqlops"
+	clientset "github.com/giantswarm/operator-workshop/pkg/client"
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+	"github.com/spf13/cobra"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPlanCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "plan",
+		Short: "Print the changes a reconciliation would apply and exit.",
+		Long: `Print the changes a reconciliation would apply and exit.
+
+The command lists the custom objects and the databases and prints a
+terraform-style diff of creates, owner changes and deletes without
+starting the reconcile loop. Deletes are only applied by a running
+operator with orphan deletion enabled.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printPlan()
+		},
+	}
+}
+
+func printPlan() error {
+	restConfig, err := newRestConfig()
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating containerconf clientset: %s", err)
+	}
+
+	err = resolveDatabase()
+	if err != nil {
+		return err
+	}
+
+	// Diff the PostgreSQL databases.
+	{
+		objs, err := k8sClient.ContainerconfV1().PostgreSQLConfigs(f.Namespace).List(apismetav1.ListOptions{LabelSelector: f.LabelSelector})
+		if err != nil {
+			return fmt.Errorf("listing postgresqlconfigs: %s", err)
+		}
+
+		ops, err := postgresqlops.New(postgresqlops.Config{
+			Host:         f.DBHost,
+			Port:         f.DBPort,
+			User:         f.DBUser,
+			Password:     f.DBPassword,
+			PasswordFile: f.DBPasswordFile,
+		})
+		if err != nil {
+			return fmt.Errorf("creating PostgreSQLOps: %s", err)
+		}
+		defer ops.Close()
+
+		specs := []postgresqlops.DatabaseSpec{}
+		for i := range objs.Items {
+			obj := &objs.Items[i].PostgreSQLConfig
+			if f.NamespacePrefix {
+				obj = customobject.NamespacePrefixed(obj, objs.Items[i].Namespace)
+			}
+			specs = append(specs, postgresqlops.DatabaseSpec{Name: obj.Spec.Database, Owner: obj.Spec.Owner})
+		}
+
+		diff, err := ops.Diff(specs)
+		if err != nil {
+			return fmt.Errorf("diffing databases: %s", err)
+		}
+
+		creates := map[string]string{}
+		for _, spec := range diff.Creates {
+			creates[spec.Name] = spec.Owner
+		}
+		updates := []ownerChange{}
+		for _, change := range diff.Updates {
+			updates = append(updates, ownerChange{name: change.Name, from: change.From, to: change.To})
+		}
+		deletes := map[string]string{}
+		for _, db := range diff.Deletes {
+			deletes[db.Name] = db.Owner
+		}
+
+		fmt.Println("PostgreSQL:")
+		printDiff(creates, updates, deletes)
+	}
+
+	// Diff the MySQL databases when a MySQL server is configured.
+	if f.MySQLHost != "" {
+		objs, err := k8sClient.ContainerconfV1().MySQLConfigs(f.Namespace).List(apismetav1.ListOptions{LabelSelector: f.LabelSelector})
+		if err != nil {
+			return fmt.Errorf("listing mysqlconfigs: %s", err)
+		}
+
+		ops, err := mysqlops.New(mysqlops.Config{
+			Host:     f.MySQLHost,
+			Port:     f.MySQLPort,
+			User:     f.MySQLUser,
+			Password: f.MySQLPassword,
+		})
+		if err != nil {
+			return fmt.Errorf("creating MySQLOps: %s", err)
+		}
+		defer ops.Close()
+
+		specs := []mysqlops.DatabaseSpec{}
+		for i := range objs.Items {
+			specs = append(specs, mysqlops.DatabaseSpec{Name: objs.Items[i].Spec.Database, Owner: objs.Items[i].Spec.Owner})
+		}
+
+		diff, err := ops.Diff(specs)
+		if err != nil {
+			return fmt.Errorf("diffing mysql databases: %s", err)
+		}
+
+		creates := map[string]string{}
+		for _, spec := range diff.Creates {
+			creates[spec.Name] = spec.Owner
+		}
+		updates := []ownerChange{}
+		for _, change := range diff.Updates {
+			updates = append(updates, ownerChange{name: change.Name, from: change.From, to: change.To})
+		}
+		deletes := map[string]string{}
+		for _, db := range diff.Deletes {
+			deletes[db.Name] = db.Owner
+		}
+
+		fmt.Println("MySQL:")
+		printDiff(creates, updates, deletes)
+	}
+
+	return nil
+}
+
+// ownerChange is one owner drift line of the printed diff.
+type ownerChange struct {
+	name string
+	from string
+	to   string
+}
+
+// printDiff prints a terraform-style diff, creates first, then owner
+// changes, then deletes, each sorted by database name.
+func printDiff(creates map[string]string, updates []ownerChange, deletes map[string]string) {
+	changes := 0
+
+	for _, name := range sortedKeys(creates) {
+		fmt.Printf("  + database %#q owner=%s\n", name, creates[name])
+		changes++
+	}
+
+	sort.Slice(updates, func(i, j int) bool { return updates[i].name < updates[j].name })
+	for _, change := range updates {
+		fmt.Printf("  ~ database %#q owner: %s -> %s\n", change.name, change.from, change.to)
+		changes++
+	}
+
+	for _, name := range sortedKeys(deletes) {
+		fmt.Printf("  - database %#q owner=%s\n", name, deletes[name])
+		changes++
+	}
+
+	if changes == 0 {
+		fmt.Println("  no changes")
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
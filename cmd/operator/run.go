@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/discovery"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
+	"github.com/giantswarm/operator-workshop/solution1"
+	"github.com/giantswarm/operator-workshop/solution2"
+	"github.com/giantswarm/operator-workshop/solution3"
+	"github.com/giantswarm/operator-workshop/solution4"
+	"github.com/giantswarm/operator-workshop/tracing"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newRunCommand(d defaults) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the selected solution until interrupted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := resolveDatabase()
+			if err != nil {
+				return err
+			}
+			err = runChecks(false)
+			if err != nil {
+				return err
+			}
+			runOperator()
+			return nil
+		},
+	}
+
+	fl := cmd.Flags()
+	fl.StringVar(&f.Solution, "solution", "solution3", "Solution to run, one of \"solution1\", \"solution2\", \"solution3\" and \"solution4\".")
+	addDatabaseFlags(fl)
+	fl.BoolVar(&f.EnableOrphanDeletion, "enable-orphan-deletion", false, "Enable deletion of managed databases without a matching custom object. Only solution1.")
+	fl.StringVar(&f.K8sToken, "kubernetes.token", "", "Kubernetes bearer token. Only solution1.")
+	fl.StringVar(&f.K8sTokenFile, "kubernetes.token-file", "", "Kubernetes bearer token file path. Only solution1.")
+	fl.StringVar(&f.K8sExecCommand, "kubernetes.exec-command", "", "Exec credential plugin command printing an ExecCredential object. Only solution1.")
+	fl.DurationVar(&f.ResyncPeriod, "kubernetes.resync-period", time.Minute, "How often all known objects are replayed through the update path.")
+	fl.DurationVar(&f.RateWait, "kubernetes.rate-wait", 0, "Time to wait between releasing single update events. 0 releases all at once. Only solution3.")
+	fl.BoolVar(&f.SharedInformer, "kubernetes.shared-informer", false, "Use a shared index informer instead of the plain informer. Only solution2.")
+	fl.DurationVar(&f.HTTPRequestTimeout, "http.request-timeout", 30*time.Second, "Timeout for single Kubernetes API requests. Doesn't apply to watches. Only solution1.")
+	fl.DurationVar(&f.HTTPDialTimeout, "http.dial-timeout", 10*time.Second, "Timeout for establishing TCP connections. Only solution1.")
+	fl.DurationVar(&f.HTTPTLSHandshakeTimeout, "http.tls-handshake-timeout", 10*time.Second, "Timeout for the TLS handshake. Only solution1.")
+	fl.IntVar(&f.HTTPMaxIdleConns, "http.max-idle-conns", 5, "Maximum idle HTTP connections kept for reuse. Only solution1.")
+	fl.IntVar(&f.Workers, "workers", 1, "Number of concurrent object reconciliations.")
+	fl.BoolVar(&f.Debug, "debug", false, "Log method, URL, status and latency of every Kubernetes API call. Only solution1.")
+	fl.DurationVar(&f.GracefulTimeout, "graceful-timeout", 30*time.Second, "How long a graceful stop may take to finish the current reconcile and close connections before the process force-exits. 0 waits forever.")
+	fl.BoolVar(&f.DryRun, "dry-run", false, "Log mutating actions, SQL statements and Kubernetes writes, instead of performing them.")
+	fl.DurationVar(&f.WatchUnhealthyAfter, "watch.unhealthy-after", 5*time.Minute, "How long the operator may run without a live watch stream before reporting unhealthy.")
+	fl.StringVar(&f.HealthAddr, "health-addr", "", "Address to serve liveness and readiness probe endpoints on. Empty disables them.")
+	fl.StringVar(&f.MetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on. Empty disables the endpoint.")
+	fl.BoolVar(&f.LeaderElect, "leader-elect", false, "Enable leader election before reconciling.")
+	fl.StringVar(&f.LeaderElectIdentity, "leader-elect.identity", d.hostname, "Leader election identity. Must be unique per replica.")
+	fl.DurationVar(&f.LeaderElectLeaseDuration, "leader-elect.lease-duration", 15*time.Second, "How long a leadership is valid without renewal.")
+	fl.DurationVar(&f.SlowReconcileThreshold, "slow-reconcile-threshold", 5*time.Second, "Duration above which a reconcile logs a warning with a latency breakdown. 0 disables the detection.")
+	fl.StringVar(&f.TracingEndpoint, "tracing.endpoint", "", "OTLP/HTTP collector base URL, e.g. \"http://localhost:4318\". Empty disables tracing.")
+
+	return cmd
+}
+
+// addDatabaseFlags registers the database connection flags shared by the
+// run and plan subcommands.
+func addDatabaseFlags(fl *pflag.FlagSet) {
+	fl.StringVar(&f.DBHost, "postgresql.host", "", "PostgreSQL server host. Empty resolves --postgresql.service through the Kubernetes API.")
+	fl.IntVar(&f.DBPort, "postgresql.port", 5432, "PostgreSQL server port. Ignored when the host is resolved through the Kubernetes API.")
+	fl.StringVar(&f.DBService, "postgresql.service", dbServiceDefault, "PostgreSQL Service name resolved when --postgresql.host is empty.")
+	fl.StringVar(&f.DBServiceNamespace, "postgresql.service-namespace", "default", "Namespace of the PostgreSQL Service.")
+	fl.StringVar(&f.DBDiscoveryMode, "postgresql.discovery", discovery.ModeAuto, "Service discovery mode, one of \"auto\", \"clusterip\", \"nodeport\" and \"port-forward\".")
+	fl.StringVar(&f.DBUser, "postgresql.user", dbUserDefault, "PostgreSQL user.")
+	fl.StringVar(&f.DBPassword, "postgresql.password", "", "PostgreSQL password.")
+	fl.StringVar(&f.DBPasswordFile, "postgresql.password-file", "", "File holding the PostgreSQL password, e.g. a mounted Secret volume. Takes precedence over --postgresql.password.")
+	fl.StringVar(&f.MySQLHost, "mysql.host", "", "MySQL server host. Empty disables the MySQL reconciler.")
+	fl.IntVar(&f.MySQLPort, "mysql.port", 3306, "MySQL server port.")
+	fl.StringVar(&f.MySQLUser, "mysql.user", "root", "MySQL user.")
+	fl.StringVar(&f.MySQLPassword, "mysql.password", "", "MySQL password.")
+	fl.BoolVar(&f.NamespacePrefix, "database.namespaceprefix", false, "Prefix database names with the object namespace.")
+}
+
+// resolveDatabase resolves the database host and port through the
+// Kubernetes API when no host is set explicitly.
+func resolveDatabase() error {
+	if f.DBHost != "" {
+		return nil
+	}
+
+	restConfig, err := newRestConfig()
+	if err != nil {
+		return err
+	}
+
+	mode := f.DBDiscoveryMode
+	if f.K8sInCluster && mode == discovery.ModeAuto {
+		mode = discovery.ModeClusterIP
+	}
+
+	host, port, err := discovery.Resolve(discovery.Config{
+		RestConfig: restConfig,
+		Namespace:  f.DBServiceNamespace,
+		Service:    f.DBService,
+		Mode:       mode,
+	})
+	if err != nil {
+		return fmt.Errorf("resolving postgresql service %#q: %s", f.DBService, err)
+	}
+	f.DBHost = host
+	f.DBPort = port
+
+	return nil
+}
+
+// runOperator runs the selected solution handling graceful stop on the
+// first interrupt. It doesn't return, the exit code reports the outcome.
+func runOperator() {
+	ctx := context.Background()
+
+	reconcilemetrics.SetSlowThreshold(f.SlowReconcileThreshold)
+
+	tracing.Configure(tracing.Config{
+		Endpoint:    f.TracingEndpoint,
+		ServiceName: "operator-workshop-" + f.Solution,
+	})
+
+	mainExitCodeCh := make(chan int)
+	mainCtx, mainCancelFunc := context.WithCancel(ctx)
+
+	// Run actual code.
+	go func() {
+		err := runSolution(mainCtx, f)
+		if err != nil {
+			logging.Errorf("%s", err)
+			mainExitCodeCh <- 1
+		}
+		mainExitCodeCh <- 0
+	}()
+
+	// SIGTERM is what Kubernetes sends when stopping a Pod. os.Kill
+	// cannot be caught.
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	// Handle graceful stop.
+	gracefulStop := false
+	var gracefulTimeoutCh <-chan time.Time
+	for {
+		select {
+		case code := <-mainExitCodeCh:
+			logging.Infof("exiting: code=%d", code)
+			os.Exit(code)
+		case <-gracefulTimeoutCh:
+			logging.Errorf("exiting: graceful stop timed out after %s, forced exit code=1", f.GracefulTimeout)
+			os.Exit(1)
+		case <-sigCh:
+			// On second signal exit immediately.
+			if gracefulStop {
+				logging.Infof("exiting: forced exit code=1")
+				os.Exit(1)
+			}
+			logging.Infof("exiting: trying to preform graceful stop")
+			gracefulStop = true
+			if f.GracefulTimeout > 0 {
+				gracefulTimeoutCh = time.After(f.GracefulTimeout)
+			}
+			mainCancelFunc()
+		}
+	}
+}
+
+// runSolution dispatches to the selected solution, mapping the shared flags
+// onto its configuration.
+func runSolution(ctx context.Context, f flags) error {
+	switch f.Solution {
+	case "solution1":
+		return solution1.Run(ctx, solution1.Config{
+			DBHost:                  f.DBHost,
+			DBPort:                  f.DBPort,
+			DBUser:                  f.DBUser,
+			DBPassword:              f.DBPassword,
+			DBPasswordFile:          f.DBPasswordFile,
+			EnableOrphanDeletion:    f.EnableOrphanDeletion,
+			K8sInCluster:            f.K8sInCluster,
+			K8sServer:               f.K8sServer,
+			K8sCrtFile:              f.K8sCrtFile,
+			K8sKeyFile:              f.K8sKeyFile,
+			K8sCAFile:               f.K8sCAFile,
+			K8sToken:                f.K8sToken,
+			K8sTokenFile:            f.K8sTokenFile,
+			K8sExecCommand:          f.K8sExecCommand,
+			K8sQPS:                  f.K8sQPS,
+			K8sBurst:                f.K8sBurst,
+			Namespace:               f.Namespace,
+			LabelSelector:           f.LabelSelector,
+			HTTPRequestTimeout:      f.HTTPRequestTimeout,
+			HTTPDialTimeout:         f.HTTPDialTimeout,
+			HTTPTLSHandshakeTimeout: f.HTTPTLSHandshakeTimeout,
+			HTTPMaxIdleConns:        f.HTTPMaxIdleConns,
+			Workers:                 f.Workers,
+			Debug:                   f.Debug,
+			DryRun:                  f.DryRun,
+			WatchUnhealthyAfter:     f.WatchUnhealthyAfter,
+			HealthAddr:              f.HealthAddr,
+			MetricsAddr:             f.MetricsAddr,
+		})
+	case "solution2":
+		return solution2.Run(ctx, solution2.Config{
+			DBHost:                   f.DBHost,
+			DBPort:                   f.DBPort,
+			DBUser:                   f.DBUser,
+			DBPassword:               f.DBPassword,
+			DBPasswordFile:           f.DBPasswordFile,
+			MySQLHost:                f.MySQLHost,
+			MySQLPort:                f.MySQLPort,
+			MySQLUser:                f.MySQLUser,
+			MySQLPassword:            f.MySQLPassword,
+			NamespacePrefix:          f.NamespacePrefix,
+			K8sInCluster:             f.K8sInCluster,
+			K8sServer:                f.K8sServer,
+			K8sCrtFile:               f.K8sCrtFile,
+			K8sKeyFile:               f.K8sKeyFile,
+			K8sCAFile:                f.K8sCAFile,
+			K8sQPS:                   f.K8sQPS,
+			K8sBurst:                 f.K8sBurst,
+			Namespace:                f.Namespace,
+			LabelSelector:            f.LabelSelector,
+			ResyncPeriod:             f.ResyncPeriod,
+			SharedInformer:           f.SharedInformer,
+			DryRun:                   f.DryRun,
+			Workers:                  f.Workers,
+			WatchUnhealthyAfter:      f.WatchUnhealthyAfter,
+			HealthAddr:               f.HealthAddr,
+			MetricsAddr:              f.MetricsAddr,
+			LeaderElect:              f.LeaderElect,
+			LeaderElectIdentity:      f.LeaderElectIdentity,
+			LeaderElectLeaseDuration: f.LeaderElectLeaseDuration,
+		})
+	case "solution3":
+		return solution3.Run(ctx, solution3.Config{
+			DBHost:                   f.DBHost,
+			DBPort:                   f.DBPort,
+			DBUser:                   f.DBUser,
+			DBPassword:               f.DBPassword,
+			DBPasswordFile:           f.DBPasswordFile,
+			MySQLHost:                f.MySQLHost,
+			MySQLPort:                f.MySQLPort,
+			MySQLUser:                f.MySQLUser,
+			MySQLPassword:            f.MySQLPassword,
+			NamespacePrefix:          f.NamespacePrefix,
+			K8sInCluster:             f.K8sInCluster,
+			K8sServer:                f.K8sServer,
+			K8sCrtFile:               f.K8sCrtFile,
+			K8sKeyFile:               f.K8sKeyFile,
+			K8sCAFile:                f.K8sCAFile,
+			K8sQPS:                   f.K8sQPS,
+			K8sBurst:                 f.K8sBurst,
+			Namespace:                f.Namespace,
+			LabelSelector:            f.LabelSelector,
+			ResyncPeriod:             f.ResyncPeriod,
+			RateWait:                 f.RateWait,
+			DryRun:                   f.DryRun,
+			HealthAddr:               f.HealthAddr,
+			MetricsAddr:              f.MetricsAddr,
+			LeaderElect:              f.LeaderElect,
+			LeaderElectIdentity:      f.LeaderElectIdentity,
+			LeaderElectLeaseDuration: f.LeaderElectLeaseDuration,
+		})
+	case "solution4":
+		return solution4.Run(ctx, solution4.Config{
+			DBHost:          f.DBHost,
+			DBPort:          f.DBPort,
+			DBUser:          f.DBUser,
+			DBPassword:      f.DBPassword,
+			DBPasswordFile:  f.DBPasswordFile,
+			MySQLHost:       f.MySQLHost,
+			MySQLPort:       f.MySQLPort,
+			MySQLUser:       f.MySQLUser,
+			MySQLPassword:   f.MySQLPassword,
+			NamespacePrefix: f.NamespacePrefix,
+			K8sInCluster:    f.K8sInCluster,
+			K8sServer:       f.K8sServer,
+			K8sCrtFile:      f.K8sCrtFile,
+			K8sKeyFile:      f.K8sKeyFile,
+			K8sCAFile:       f.K8sCAFile,
+			K8sQPS:          f.K8sQPS,
+			K8sBurst:        f.K8sBurst,
+			Namespace:       f.Namespace,
+			LabelSelector:   f.LabelSelector,
+			DryRun:          f.DryRun,
+			HealthAddr:      f.HealthAddr,
+		})
+	}
+
+	return fmt.Errorf("unknown solution %#q", f.Solution)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/operator-workshop/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the build information and exit.",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(buildinfo.String())
+		},
+	}
+}
@@ -3,29 +3,35 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"os/user"
 	"path"
-	"strconv"
-	"strings"
+	"syscall"
+	"time"
 
+	"github.com/giantswarm/operator-workshop/buildinfo"
+	"github.com/giantswarm/operator-workshop/discovery"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
 	"github.com/giantswarm/operator-workshop/solution3"
+	"github.com/giantswarm/operator-workshop/tracing"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
+// gracefulTimeout bounds the graceful stop after the first signal. Set in
+// parseFlags.
+var gracefulTimeout time.Duration
+
 const (
 	dbServiceDefault  = "workshop-postgresql"
 	dbUserDefault     = "postgres"
 	dbPasswordDefault = "operator-workshop"
 )
 
-func init() {
-	log.SetFlags(log.Ldate | log.Ltime | log.LUTC)
-	log.SetPrefix("I ")
-}
-
 func main() {
 	ctx := context.Background()
 
@@ -38,34 +44,40 @@ func main() {
 	go func() {
 		err := solution3.Run(mainCtx, config)
 		if err != nil {
-			log.SetPrefix("E ")
-			log.Printf("%s", err)
+			logging.Errorf("%s", err)
 			mainExitCodeCh <- 1
 		}
 		mainExitCodeCh <- 0
 	}()
 
+	// SIGTERM is what Kubernetes sends when stopping a Pod. os.Kill
+	// cannot be caught.
 	sigCh := make(chan os.Signal, 2)
-	signal.Notify(sigCh, os.Interrupt, os.Kill)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
 	// Handle graceful stop.
 	gracefulStop := false
+	var gracefulTimeoutCh <-chan time.Time
 	for {
 		select {
 		case code := <-mainExitCodeCh:
-			log.Printf("exiting: code=%d", code)
+			logging.Infof("exiting: code=%d", code)
 			os.Exit(code)
-		case sig := <-sigCh:
-			// On second SIGKILL exit immediately.
-			if sig == os.Kill && gracefulStop {
-				log.Printf("exiting: forced exit code=1")
+		case <-gracefulTimeoutCh:
+			logging.Errorf("exiting: graceful stop timed out after %s, forced exit code=1", gracefulTimeout)
+			os.Exit(1)
+		case <-sigCh:
+			// On second signal exit immediately.
+			if gracefulStop {
+				logging.Infof("exiting: forced exit code=1")
 				os.Exit(1)
 			}
-			if !gracefulStop {
-				log.Printf("exiting: trying to preform graceful stop")
-				gracefulStop = true
-				mainCancelFunc()
+			logging.Infof("exiting: trying to preform graceful stop")
+			gracefulStop = true
+			if gracefulTimeout > 0 {
+				gracefulTimeoutCh = time.After(gracefulTimeout)
 			}
+			mainCancelFunc()
 		}
 	}
 }
@@ -84,43 +96,123 @@ func parseFlags() solution3.Config {
 
 	}
 
-	var minikubeIP string
-	{
-		out, err := exec.Command("minikube", "ip").Output()
-		if err == nil {
-			minikubeIP = strings.TrimSpace(string(out))
-		}
-	}
+	hostname, _ := os.Hostname()
 
 	var serverDefault string
 	{
-		if minikubeIP != "" {
-			serverDefault = "https://" + string(minikubeIP) + ":8443"
-		}
-	}
-
-	var dbPortDefault int
-	{
-		out, err := exec.Command("minikube", "service", dbServiceDefault, "--format", "{{.Port}}").Output()
+		kubeconfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
 		if err == nil {
-			s := strings.TrimSpace(string(out))
-			dbPortDefault, err = strconv.Atoi(s)
-			if err != nil {
-				dbPortDefault = 0
+			context, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]
+			if ok {
+				cluster, ok := kubeconfig.Clusters[context.Cluster]
+				if ok {
+					serverDefault = cluster.Server
+				}
 			}
 		}
 	}
 
-	flag.StringVar(&config.DBHost, "postgresql.host", minikubeIP, "PostgreSQL server host.")
-	flag.IntVar(&config.DBPort, "postgresql.port", dbPortDefault, "PostgreSQL server port.")
+	flag.StringVar(&config.DBHost, "postgresql.host", "", "PostgreSQL server host. Empty resolves -postgresql.service through the Kubernetes API.")
+	flag.IntVar(&config.DBPort, "postgresql.port", 5432, "PostgreSQL server port. Ignored when the host is resolved through the Kubernetes API.")
+	var dbService string
+	flag.StringVar(&dbService, "postgresql.service", dbServiceDefault, "PostgreSQL Service name resolved when -postgresql.host is empty.")
+	var dbServiceNamespace string
+	flag.StringVar(&dbServiceNamespace, "postgresql.service-namespace", "default", "Namespace of the PostgreSQL Service.")
+	var dbDiscoveryMode string
+	flag.StringVar(&dbDiscoveryMode, "postgresql.discovery", discovery.ModeAuto, "Service discovery mode, one of \"auto\", \"clusterip\", \"nodeport\" and \"port-forward\".")
 	flag.StringVar(&config.DBUser, "postgresql.user", dbUserDefault, "PostgreSQL user.")
 	flag.StringVar(&config.DBPassword, "postgresql.password", dbPasswordDefault, "PostgreSQL password.")
+	flag.StringVar(&config.DBPasswordFile, "postgresql.password-file", "", "File holding the PostgreSQL password, e.g. a mounted Secret volume. Takes precedence over -postgresql.password.")
+	flag.StringVar(&config.MySQLHost, "mysql.host", "", "MySQL server host. Empty disables the MySQL reconciler.")
+	flag.IntVar(&config.MySQLPort, "mysql.port", 3306, "MySQL server port.")
+	flag.StringVar(&config.MySQLUser, "mysql.user", "root", "MySQL user.")
+	flag.StringVar(&config.MySQLPassword, "mysql.password", "", "MySQL password.")
+	flag.BoolVar(&config.NamespacePrefix, "database.namespaceprefix", false, "Prefix database names with the object namespace.")
 	flag.BoolVar(&config.K8sInCluster, "kubernetes.incluster", false, "Run inside Kubernets cluster.")
 	flag.StringVar(&config.K8sServer, "kubernetes.server", serverDefault, "Kubernetes API server address.")
 	flag.StringVar(&config.K8sCrtFile, "kubernetes.crt", path.Join(homeDir, ".minikube/apiserver.crt"), "Kubernetes certificate file path.")
 	flag.StringVar(&config.K8sKeyFile, "kubernetes.key", path.Join(homeDir, ".minikube/apiserver.key"), "Kubernetes key file path.")
 	flag.StringVar(&config.K8sCAFile, "kubernetes.ca", path.Join(homeDir, ".minikube/ca.crt"), "Kubernetes CA file path.")
+	flag.StringVar(&config.Namespace, "kubernetes.namespace", "", "Namespace to watch. Empty watches all namespaces.")
+	flag.StringVar(&config.LabelSelector, "kubernetes.selector", "", "Label selector filtering watched objects.")
+	flag.Float64Var(&config.K8sQPS, "kubernetes.qps", 0, "Maximum queries per second against the Kubernetes API. 0 uses the client default.")
+	flag.DurationVar(&config.ResyncPeriod, "kubernetes.resync-period", time.Minute, "How often all known objects are replayed through the update path.")
+	flag.DurationVar(&config.RateWait, "kubernetes.rate-wait", 0, "Time to wait between releasing single update events. 0 releases all at once.")
+	flag.StringVar(&config.MetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on. Empty disables the endpoint.")
+	flag.StringVar(&config.HealthAddr, "health-addr", "", "Address to serve liveness and readiness probe endpoints on. Empty disables them.")
+	flag.BoolVar(&config.LeaderElect, "leader-elect", false, "Enable leader election before reconciling.")
+	flag.StringVar(&config.LeaderElectIdentity, "leader-elect.identity", hostname, "Leader election identity. Must be unique per replica.")
+	flag.DurationVar(&config.LeaderElectLeaseDuration, "leader-elect.lease-duration", 15*time.Second, "How long a leadership is valid without renewal.")
+	flag.IntVar(&config.K8sBurst, "kubernetes.burst", 0, "Maximum burst of queries against the Kubernetes API.")
+	flag.DurationVar(&gracefulTimeout, "graceful-timeout", 30*time.Second, "How long a graceful stop may take to finish the current reconcile and close connections before the process force-exits. 0 waits forever.")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "Log mutating actions, SQL statements and Kubernetes writes, instead of performing them.")
+	var slowReconcileThreshold time.Duration
+	flag.DurationVar(&slowReconcileThreshold, "slow-reconcile-threshold", 5*time.Second, "Duration above which a reconcile logs a warning with a latency breakdown. 0 disables the detection.")
+	var tracingEndpoint string
+	flag.StringVar(&tracingEndpoint, "tracing.endpoint", "", "OTLP/HTTP collector base URL, e.g. \"http://localhost:4318\". Empty disables tracing.")
+	var logFormat string
+	flag.StringVar(&logFormat, "log-format", logging.FormatText, "Log output format, \"text\" or \"json\".")
+	var logLevel string
+	flag.StringVar(&logLevel, "log-level", "info", "Minimum level logged, one of \"debug\", \"info\", \"warn\" and \"error\".")
+	showVersion := flag.Bool("version", false, "Print the build information and exit.")
 	flag.Parse()
 
+	if *showVersion {
+		fmt.Println(buildinfo.String())
+		os.Exit(0)
+	}
+
+	err := logging.Configure(logging.Config{Format: logFormat, Level: logLevel})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	reconcilemetrics.SetSlowThreshold(slowReconcileThreshold)
+
+	tracing.Configure(tracing.Config{
+		Endpoint:    tracingEndpoint,
+		ServiceName: "operator-workshop-solution3",
+	})
+
+	if config.DBHost == "" {
+		var restConfig *rest.Config
+		if config.K8sInCluster {
+			var err error
+			restConfig, err = rest.InClusterConfig()
+			if err != nil {
+				logging.Errorf("creating incluster config: %s", err)
+				os.Exit(2)
+			}
+		} else {
+			restConfig = &rest.Config{
+				Host: config.K8sServer,
+				TLSClientConfig: rest.TLSClientConfig{
+					CertFile: config.K8sCrtFile,
+					KeyFile:  config.K8sKeyFile,
+					CAFile:   config.K8sCAFile,
+				},
+			}
+		}
+
+		mode := dbDiscoveryMode
+		if config.K8sInCluster && mode == discovery.ModeAuto {
+			mode = discovery.ModeClusterIP
+		}
+
+		host, port, err := discovery.Resolve(discovery.Config{
+			RestConfig: restConfig,
+			Namespace:  dbServiceNamespace,
+			Service:    dbService,
+			Mode:       mode,
+		})
+		if err != nil {
+			logging.Errorf("resolving postgresql service %#q: %s", dbService, err)
+			os.Exit(2)
+		}
+		config.DBHost = host
+		config.DBPort = port
+	}
+
 	return config
 }
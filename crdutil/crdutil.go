@@ -0,0 +1,90 @@
+// Package crdutil provides helpers for CRD features not expressible with
+// the vendored apiextensions types. The vendored v1beta1 predates
+// additionalPrinterColumns, so the columns are applied with a JSON merge
+// patch after the CRD is created. Older apiservers drop the unknown field.
+package crdutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewCRD creates a CustomResourceDefinition object for a containerconf.de
+// kind.
+func NewCRD(plural, singular, kind string) *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		TypeMeta: apismetav1.TypeMeta{
+			APIVersion: "apiextensions.k8s.io/v1beta1",
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: plural + ".containerconf.de",
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   "containerconf.de",
+			Version: "v1",
+			Scope:   apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural:     plural,
+				Singular:   singular,
+				Kind:       kind,
+				ShortNames: []string{},
+			},
+		},
+	}
+}
+
+// PrinterColumn describes one additionalPrinterColumns entry.
+type PrinterColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	JSONPath string `json:"JSONPath"`
+}
+
+// ConfigColumns are the columns shown by kubectl get for the database
+// config kinds. Both PostgreSQLConfig and MySQLConfig declare database and
+// owner.
+var ConfigColumns = []PrinterColumn{
+	{Name: "Database", Type: "string", JSONPath: ".spec.database"},
+	{Name: "Owner", Type: "string", JSONPath: ".spec.owner"},
+	{Name: "Phase", Type: "string", JSONPath: ".status.phase"},
+	{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+}
+
+// ServerColumns are the columns shown by kubectl get for the
+// PostgreSQLServer kind.
+var ServerColumns = []PrinterColumn{
+	{Name: "Host", Type: "string", JSONPath: ".spec.host"},
+	{Name: "Port", Type: "integer", JSONPath: ".spec.port"},
+	{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+}
+
+// EnsurePrinterColumns patches the named CRD with the given printer
+// columns.
+func EnsurePrinterColumns(restClient rest.Interface, crdName string, columns []PrinterColumn) error {
+	patch := struct {
+		Spec struct {
+			AdditionalPrinterColumns []PrinterColumn `json:"additionalPrinterColumns"`
+		} `json:"spec"`
+	}{}
+	patch.Spec.AdditionalPrinterColumns = columns
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("marshalling printer columns patch: %s", err)
+	}
+
+	endpoint := fmt.Sprintf("/apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions/%s", crdName)
+	err = restClient.Patch(types.MergePatchType).AbsPath(endpoint).Body(body).Do().Error()
+	if err != nil {
+		return fmt.Errorf("patching printer columns name=%#q: %s", crdName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package customobject
+
+// Hand-written deep copy implementations following the deepcopy-gen
+// conventions. The types are simple enough that generated code would add a
+// build dependency for little gain.
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLConfig) DeepCopyInto(out *PostgreSQLConfig) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy creates a new PostgreSQLConfig copying the receiver.
+func (in *PostgreSQLConfig) DeepCopy() *PostgreSQLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLConfigSpec) DeepCopyInto(out *PostgreSQLConfigSpec) {
+	*out = *in
+	if in.ServerRef != nil {
+		out.ServerRef = new(SecretRef)
+		*out.ServerRef = *in.ServerRef
+	}
+	if in.Users != nil {
+		out.Users = make([]UserSpec, len(in.Users))
+		for i := range in.Users {
+			out.Users[i] = in.Users[i]
+			if in.Users[i].PasswordSecretRef != nil {
+				out.Users[i].PasswordSecretRef = new(SecretRef)
+				*out.Users[i].PasswordSecretRef = *in.Users[i].PasswordSecretRef
+			}
+		}
+	}
+	if in.Backup != nil {
+		out.Backup = new(BackupSpec)
+		*out.Backup = *in.Backup
+		if in.Backup.DestinationSecretRef != nil {
+			out.Backup.DestinationSecretRef = new(SecretRef)
+			*out.Backup.DestinationSecretRef = *in.Backup.DestinationSecretRef
+		}
+	}
+	if in.MigrationsRef != nil {
+		out.MigrationsRef = new(ConfigMapRef)
+		*out.MigrationsRef = *in.MigrationsRef
+	}
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLConfigStatus) DeepCopyInto(out *PostgreSQLConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]Condition, len(in.Conditions))
+		copy(out.Conditions, in.Conditions)
+	}
+	if in.AppliedMigrations != nil {
+		out.AppliedMigrations = make([]string, len(in.AppliedMigrations))
+		copy(out.AppliedMigrations, in.AppliedMigrations)
+	}
+	if in.History != nil {
+		out.History = make([]ReconcileResult, len(in.History))
+		copy(out.History, in.History)
+	}
+	if in.Connection != nil {
+		out.Connection = new(ConnectionStatus)
+		*out.Connection = *in.Connection
+		if in.Connection.SecretRef != nil {
+			out.Connection.SecretRef = new(SecretRef)
+			*out.Connection.SecretRef = *in.Connection.SecretRef
+		}
+	}
+}
+
+// DeepCopy creates a new PostgreSQLConfigStatus copying the receiver.
+func (in *PostgreSQLConfigStatus) DeepCopy() *PostgreSQLConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopy creates a new PostgreSQLConfigSpec copying the receiver.
+func (in *PostgreSQLConfigSpec) DeepCopy() *PostgreSQLConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLConfigList) DeepCopyInto(out *PostgreSQLConfigList) {
+	*out = *in
+	if in.Items != nil {
+		out.Items = make([]*PostgreSQLConfig, len(in.Items))
+		for i := range in.Items {
+			out.Items[i] = in.Items[i].DeepCopy()
+		}
+	}
+}
+
+// DeepCopy creates a new PostgreSQLConfigList copying the receiver.
+func (in *PostgreSQLConfigList) DeepCopy() *PostgreSQLConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLServer) DeepCopyInto(out *PostgreSQLServer) {
+	*out = *in
+}
+
+// DeepCopy creates a new PostgreSQLServer copying the receiver.
+func (in *PostgreSQLServer) DeepCopy() *PostgreSQLServer {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *PostgreSQLServerList) DeepCopyInto(out *PostgreSQLServerList) {
+	*out = *in
+	if in.Items != nil {
+		out.Items = make([]*PostgreSQLServer, len(in.Items))
+		for i := range in.Items {
+			out.Items[i] = in.Items[i].DeepCopy()
+		}
+	}
+}
+
+// DeepCopy creates a new PostgreSQLServerList copying the receiver.
+func (in *PostgreSQLServerList) DeepCopy() *PostgreSQLServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MySQLConfig) DeepCopyInto(out *MySQLConfig) {
+	*out = *in
+}
+
+// DeepCopy creates a new MySQLConfig copying the receiver.
+func (in *MySQLConfig) DeepCopy() *MySQLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MySQLConfigSpec) DeepCopyInto(out *MySQLConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy creates a new MySQLConfigSpec copying the receiver.
+func (in *MySQLConfigSpec) DeepCopy() *MySQLConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *MySQLConfigList) DeepCopyInto(out *MySQLConfigList) {
+	*out = *in
+	if in.Items != nil {
+		out.Items = make([]*MySQLConfig, len(in.Items))
+		for i := range in.Items {
+			out.Items[i] = in.Items[i].DeepCopy()
+		}
+	}
+}
+
+// DeepCopy creates a new MySQLConfigList copying the receiver.
+func (in *MySQLConfigList) DeepCopy() *MySQLConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,186 @@
+package customobject
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+)
+
+// FakeOps is an in-memory DatabaseOps used in tests. The list results are
+// programmable via Databases and kept up to date by the mutating methods.
+// Errs injects an error per method name. Calls records the method calls in
+// order for assertions.
+type FakeOps struct {
+	// Host and Port are the Address result.
+	Host string
+	Port int
+	// Databases is the ListDatabases result.
+	Databases []postgresqlops.Database
+	// Trashed is the ListTrashedDatabases result.
+	Trashed []postgresqlops.Database
+	// Errs injects an error per method name, e.g. "CreateDatabase".
+	Errs map[string]error
+	// Calls records the calls in order, e.g. "CreateDatabase(db,owner)".
+	Calls []string
+	// Passwords records the password set per user.
+	Passwords map[string]string
+	// DeletedUsers records the deleted users in order.
+	DeletedUsers []string
+	// Migrations records the applied migrations per database.
+	Migrations map[string][]string
+}
+
+// call records the method call and returns the injected error, if any.
+func (f *FakeOps) call(name string, args ...string) error {
+	f.Calls = append(f.Calls, fmt.Sprintf("%s(%s)", name, strings.Join(args, ",")))
+	return f.Errs[name]
+}
+
+// Called reports whether a call with the given prefix was recorded.
+func (f *FakeOps) Called(prefix string) bool {
+	for _, c := range f.Calls {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeOps) Address() (string, int) {
+	return f.Host, f.Port
+}
+
+func (f *FakeOps) ListDatabases() ([]postgresqlops.Database, error) {
+	if err := f.call("ListDatabases"); err != nil {
+		return nil, err
+	}
+	return append([]postgresqlops.Database{}, f.Databases...), nil
+}
+
+func (f *FakeOps) ListTrashedDatabases() ([]postgresqlops.Database, error) {
+	if err := f.call("ListTrashedDatabases"); err != nil {
+		return nil, err
+	}
+	return append([]postgresqlops.Database{}, f.Trashed...), nil
+}
+
+func (f *FakeOps) CreateDatabase(name, owner string) error {
+	if err := f.call("CreateDatabase", name, owner); err != nil {
+		return err
+	}
+	f.Databases = append(f.Databases, postgresqlops.Database{Name: name, Owner: owner})
+	return nil
+}
+
+func (f *FakeOps) ChangeDatabaseOwner(name, owner string) error {
+	if err := f.call("ChangeDatabaseOwner", name, owner); err != nil {
+		return err
+	}
+	for i := range f.Databases {
+		if f.Databases[i].Name == name {
+			f.Databases[i].Owner = owner
+		}
+	}
+	return nil
+}
+
+func (f *FakeOps) RenameDatabase(oldName, newName string) error {
+	if err := f.call("RenameDatabase", oldName, newName); err != nil {
+		return err
+	}
+	for i := range f.Databases {
+		if f.Databases[i].Name == oldName {
+			f.Databases[i].Name = newName
+		}
+	}
+	return nil
+}
+
+func (f *FakeOps) DeleteDatabase(name string) error {
+	if err := f.call("DeleteDatabase", name); err != nil {
+		return err
+	}
+	var kept []postgresqlops.Database
+	for _, db := range f.Databases {
+		if db.Name != name {
+			kept = append(kept, db)
+		}
+	}
+	f.Databases = kept
+	return nil
+}
+
+func (f *FakeOps) DeleteUser(name string) error {
+	if err := f.call("DeleteUser", name); err != nil {
+		return err
+	}
+	f.DeletedUsers = append(f.DeletedUsers, name)
+	return nil
+}
+
+func (f *FakeOps) RevokePublic(name string) error {
+	return f.call("RevokePublic", name)
+}
+
+func (f *FakeOps) GrantAllOnDatabase(name, user string) error {
+	return f.call("GrantAllOnDatabase", name, user)
+}
+
+func (f *FakeOps) GrantReadOnly(name, user string) error {
+	return f.call("GrantReadOnly", name, user)
+}
+
+func (f *FakeOps) SetConnectionLimit(name string, limit int) error {
+	return f.call("SetConnectionLimit", name, fmt.Sprintf("%d", limit))
+}
+
+func (f *FakeOps) SetUserPassword(user, password string) error {
+	if err := f.call("SetUserPassword", user); err != nil {
+		return err
+	}
+	if f.Passwords == nil {
+		f.Passwords = map[string]string{}
+	}
+	f.Passwords[user] = password
+	return nil
+}
+
+func (f *FakeOps) RotateUserPassword(ctx context.Context, user string) (string, error) {
+	if err := f.call("RotateUserPassword", user); err != nil {
+		return "", err
+	}
+	if f.Passwords == nil {
+		f.Passwords = map[string]string{}
+	}
+	f.Passwords[user] = "rotated-" + user
+	return f.Passwords[user], nil
+}
+
+func (f *FakeOps) AppliedMigrations(name string) ([]string, error) {
+	if err := f.call("AppliedMigrations", name); err != nil {
+		return nil, err
+	}
+	return append([]string{}, f.Migrations[name]...), nil
+}
+
+func (f *FakeOps) ApplyMigration(name, migration, migrationSQL string) error {
+	if err := f.call("ApplyMigration", name, migration); err != nil {
+		return err
+	}
+	if f.Migrations == nil {
+		f.Migrations = map[string][]string{}
+	}
+	f.Migrations[name] = append(f.Migrations[name], migration)
+	return nil
+}
+
+func (f *FakeOps) DumpDatabase(ctx context.Context, name string, w io.Writer) error {
+	return f.call("DumpDatabase", name)
+}
+
+func (f *FakeOps) RestoreDatabase(ctx context.Context, name string, r io.Reader) error {
+	return f.call("RestoreDatabase", name)
+}
@@ -0,0 +1,22 @@
+package customobject
+
+// MySQLConfig is custom object of mysqlconfigs.containerconf.de custom
+// resource.
+type MySQLConfig struct {
+	Spec MySQLConfigSpec `json:"spec"`
+}
+
+// MySQLConfigSpec is custom object specification. Represents the desired
+// state towards which the operator reconciles. It also includes information
+// necessary to perform the reconciliation, i.e. database access information.
+type MySQLConfigSpec struct {
+	// Database is database name to be created.
+	Database string `json:"database"`
+	// Owner is the user granted all privileges on the database.
+	Owner string `json:"owner"`
+	// CharacterSet is an optional database character set, e.g. "utf8mb4".
+	CharacterSet string `json:"characterSet,omitempty"`
+	// Collation is an optional database collation, e.g.
+	// "utf8mb4_unicode_ci".
+	Collation string `json:"collation,omitempty"`
+}
@@ -0,0 +1,7 @@
+package customobject
+
+// MySQLConfigList represents a list of custom objects. It is useful for
+// decoding list API calls.
+type MySQLConfigList struct {
+	Items []*MySQLConfig `json:"items"`
+}
@@ -0,0 +1,110 @@
+package customobject
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/operator-workshop/mysqlops"
+)
+
+// MySQLResource represents a resource being a result of MySQLConfig object
+// reconciliation. In this case it is a MySQL database with all privileges
+// granted to a specified user.
+type MySQLResource struct {
+	ops *mysqlops.MySQLOps
+}
+
+func NewMySQLResource(ops *mysqlops.MySQLOps) *MySQLResource {
+	return &MySQLResource{
+		ops: ops,
+	}
+}
+
+// EnsureCreated is an idempotent method making sure the database resource is
+// in a state described in the custom object.
+func (r *MySQLResource) EnsureCreated(obj *MySQLConfig) (status string, err error) {
+	dbs, err := r.ops.ListDatabases()
+	if err != nil {
+		return "", fmt.Errorf("listing databases: %s", err)
+	}
+
+	db, ok := findMySQLDB(dbs, obj.Spec.Database)
+
+	if !ok {
+		err := r.ops.CreateDatabase(obj.Spec.Database, obj.Spec.Owner)
+		if err != nil {
+			return "", fmt.Errorf("creating database: %s", err)
+		}
+		err = r.ops.SetDatabaseCharset(obj.Spec.Database, obj.Spec.CharacterSet, obj.Spec.Collation)
+		if err != nil {
+			return "", fmt.Errorf("setting charset: %s", err)
+		}
+		return "database created", nil
+	}
+
+	if db.Owner != obj.Spec.Owner {
+		err := r.ops.ChangeDatabaseOwner(obj.Spec.Database, obj.Spec.Owner)
+		if err != nil {
+			return "", fmt.Errorf("chaning owner=%#q: %s", db.Owner, err)
+		}
+		return fmt.Sprintf("owner=%#q changed", db.Owner), nil
+	}
+
+	// Reconcile character set and collation drift.
+	charsetDrift := obj.Spec.CharacterSet != "" && db.CharacterSet != obj.Spec.CharacterSet
+	collationDrift := obj.Spec.Collation != "" && db.Collation != obj.Spec.Collation
+	if charsetDrift || collationDrift {
+		err := r.ops.SetDatabaseCharset(obj.Spec.Database, obj.Spec.CharacterSet, obj.Spec.Collation)
+		if err != nil {
+			return "", fmt.Errorf("setting charset: %s", err)
+		}
+		return "charset changed", nil
+	}
+
+	return "already created", nil
+}
+
+// EnsureDeleted is an idempotent method making sure the database resource
+// described in the custom object is deleted.
+func (r *MySQLResource) EnsureDeleted(obj *MySQLConfig) (status string, err error) {
+	dbs, err := r.ops.ListDatabases()
+	if err != nil {
+		return "", fmt.Errorf("listing databases: %s", err)
+	}
+
+	_, ok := findMySQLDB(dbs, obj.Spec.Database)
+
+	if ok {
+		err = r.ops.DeleteDatabase(obj.Spec.Database)
+		if err != nil {
+			return "", fmt.Errorf("deleting database: %s", err)
+		}
+		return "database deleted", nil
+	}
+
+	return "already deleted", nil
+}
+
+// CurrentDatabase returns the database described in the custom object as it
+// currently exists on the server, or nil when it doesn't exist.
+func (r *MySQLResource) CurrentDatabase(obj *MySQLConfig) (*mysqlops.Database, error) {
+	dbs, err := r.ops.ListDatabases()
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %s", err)
+	}
+
+	db, ok := findMySQLDB(dbs, obj.Spec.Database)
+	if !ok {
+		return nil, nil
+	}
+
+	return &db, nil
+}
+
+func findMySQLDB(dbs []mysqlops.Database, name string) (mysqlops.Database, bool) {
+	for _, db := range dbs {
+		if db.Name == name {
+			return db, true
+		}
+	}
+	return mysqlops.Database{}, false
+}
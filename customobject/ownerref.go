@@ -0,0 +1,22 @@
+package customobject
+
+import (
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NewOwnerReference builds an OwnerReference to a containerconf.de custom
+// object. Objects created by the operator for a custom object (Secrets,
+// ConfigMaps, Services) carry it in their metadata so they are garbage
+// collected when the custom object is deleted.
+func NewOwnerReference(kind, name string, uid types.UID) apismetav1.OwnerReference {
+	controller := true
+
+	return apismetav1.OwnerReference{
+		APIVersion: "containerconf.de/v1",
+		Kind:       kind,
+		Name:       name,
+		UID:        uid,
+		Controller: &controller,
+	}
+}
@@ -1,9 +1,166 @@
 package customobject
 
+import "time"
+
 // PostgreSQLConfig is custom object of postgresqlconfigs.containerconf.de custom
 // resource.
 type PostgreSQLConfig struct {
-	Spec PostgreSQLConfigSpec `json:"spec"`
+	Spec   PostgreSQLConfigSpec   `json:"spec"`
+	Status PostgreSQLConfigStatus `json:"status,omitempty"`
+}
+
+// PostgreSQLConfigStatus is the observed state recorded by the operator.
+type PostgreSQLConfigStatus struct {
+	// DatabaseName is the actual database name, which differs from
+	// Spec.Database when the operator derives names, e.g. with namespace
+	// prefixing.
+	DatabaseName string `json:"databaseName,omitempty"`
+	// ObservedGeneration is the object generation last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Phase summarizes the conditions for display, e.g. in kubectl get
+	// columns.
+	Phase string `json:"phase,omitempty"`
+	// LastBackupTime is the RFC3339 time of the last successful backup.
+	LastBackupTime string `json:"lastBackupTime,omitempty"`
+	// AppliedMigrations lists the migrations applied to the database in
+	// application order.
+	AppliedMigrations []string `json:"appliedMigrations,omitempty"`
+	// Conditions describe the reconciliation state, e.g. Ready,
+	// OwnerSynced and Degraded.
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Connection describes how to connect to the provisioned database, so
+	// applications and other controllers don't re-derive operator
+	// configuration.
+	Connection *ConnectionStatus `json:"connection,omitempty"`
+	// RotatedRevision is the rotation revision last applied, see
+	// RotateUsersAnnotation.
+	RotatedRevision string `json:"rotatedRevision,omitempty"`
+	// History records the most recent reconcile results, newest first,
+	// bounded to ReconcileHistoryLimit entries. It answers questions like
+	// "how often did the owner change recently" without digging through
+	// pod logs.
+	History []ReconcileResult `json:"history,omitempty"`
+}
+
+// ReconcileResult is one entry of the bounded reconcile history.
+type ReconcileResult struct {
+	// Time is the RFC3339 time the reconciliation finished.
+	Time string `json:"time"`
+	// Outcome is the status returned by the ensure logic, e.g. "already
+	// created" or "owner changed", or "error" on failure.
+	Outcome string `json:"outcome"`
+	// Message is the error message on failure.
+	Message string `json:"message,omitempty"`
+}
+
+// ReconcileHistoryLimit bounds the length of the status reconcile history.
+const ReconcileHistoryLimit = 10
+
+// ConnectionStatus records the connection details of the provisioned
+// database.
+type ConnectionStatus struct {
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+	// Database is the actual database name.
+	Database string `json:"database,omitempty"`
+	// SecretRef is the connection Secret when the object declares
+	// Spec.ServerRef.
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+}
+
+// Condition is one aspect of the reconciliation state.
+type Condition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the last transition.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the RFC3339 time the condition last changed
+	// status.
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// PausedAnnotation suspends reconciliation of the annotated object when set
+// to "true", e.g. during maintenance windows.
+const PausedAnnotation = "containerconf.de/paused"
+
+// IsPaused reports whether the object annotations suspend reconciliation.
+func IsPaused(annotations map[string]string) bool {
+	return annotations[PausedAnnotation] == "true"
+}
+
+// RotateUsersAnnotation triggers rotation of the passwords of all users
+// declaring PasswordSecretRef. The value is an opaque revision, e.g. a
+// timestamp. Rotation runs when the value differs from
+// Status.RotatedRevision, so each revision rotates exactly once.
+const RotateUsersAnnotation = "containerconf.de/rotate-users"
+
+// RotationRevision returns the rotation revision requested by the object
+// annotations, or the empty string when no rotation is requested.
+func RotationRevision(annotations map[string]string) string {
+	return annotations[RotateUsersAnnotation]
+}
+
+// Condition types and statuses used by the operator.
+const (
+	ConditionReady       = "Ready"
+	ConditionOwnerSynced = "OwnerSynced"
+	ConditionDegraded    = "Degraded"
+	ConditionPaused      = "Paused"
+	ConditionInvalidSpec = "InvalidSpec"
+
+	ConditionTrue  = "True"
+	ConditionFalse = "False"
+)
+
+// RecordReconcile prepends one result to the reconcile history, dropping
+// the oldest entries beyond ReconcileHistoryLimit. A result repeating the
+// outcome and message of the latest entry is not recorded, so steady-state
+// reconciles leave the status unchanged instead of feeding the update watch
+// event back into another reconcile.
+func (s *PostgreSQLConfigStatus) RecordReconcile(outcome, message string) {
+	if len(s.History) > 0 && s.History[0].Outcome == outcome && s.History[0].Message == message {
+		return
+	}
+
+	entry := ReconcileResult{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Outcome: outcome,
+		Message: message,
+	}
+
+	s.History = append([]ReconcileResult{entry}, s.History...)
+	if len(s.History) > ReconcileHistoryLimit {
+		s.History = s.History[:ReconcileHistoryLimit]
+	}
+}
+
+// SetCondition updates the condition of the given type, adding it when
+// missing. LastTransitionTime only changes when the condition status
+// changes.
+func (s *PostgreSQLConfigStatus) SetCondition(conditionType, conditionStatus, reason, message string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for i, c := range s.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+		if c.Status != conditionStatus {
+			s.Conditions[i].LastTransitionTime = now
+		}
+		s.Conditions[i].Status = conditionStatus
+		s.Conditions[i].Reason = reason
+		s.Conditions[i].Message = message
+		return
+	}
+
+	s.Conditions = append(s.Conditions, Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
 }
 
 // PostgreSQLConfigSpec is custom object specification. Represents the desired state
@@ -14,4 +171,102 @@ type PostgreSQLConfigSpec struct {
 	Database string `json:"database"`
 	// Owner is the database owner.
 	Owner string `json:"owner"`
+	// ReadWriteUser is an optional user granted all privileges on the
+	// database.
+	ReadWriteUser string `json:"readWriteUser,omitempty"`
+	// ReadOnlyUser is an optional user granted connect privileges on the
+	// database.
+	ReadOnlyUser string `json:"readOnlyUser,omitempty"`
+	// RenameFrom is the previous database name. When set the existing
+	// database is renamed instead of a second database being created and
+	// the old one orphaned.
+	RenameFrom string `json:"renameFrom,omitempty"`
+	// InitialDumpURL is an optional URL of a plain-format SQL dump loaded
+	// into the database right after creation.
+	InitialDumpURL string `json:"initialDumpURL,omitempty"`
+	// ConnectionLimit is the maximum number of concurrent connections to
+	// the database. Zero means the limit is not managed by the operator.
+	ConnectionLimit int `json:"connectionLimit,omitempty"`
+	// ServerRef references a Secret holding connection details (host,
+	// port, user, password) of the Postgres server the database lives on.
+	// When not set the operator's default server is used.
+	ServerRef *SecretRef `json:"serverRef,omitempty"`
+	// Server is the name of a PostgreSQLServer object declaring the
+	// Postgres server the database lives on. It takes precedence over
+	// ServerRef. When both are empty the operator's default server is
+	// used.
+	Server string `json:"server,omitempty"`
+	// Users declares database users with their role. It extends the
+	// single ReadWriteUser and ReadOnlyUser fields to a full set of
+	// reconciled users.
+	Users []UserSpec `json:"users,omitempty"`
+	// Backup declares scheduled backups of the database. When not set no
+	// backups are taken.
+	Backup *BackupSpec `json:"backup,omitempty"`
+	// MigrationsRef references a ConfigMap of ordered SQL files applied
+	// inside the database. Keys are applied in lexical order, applied
+	// migrations are tracked in a bookkeeping table inside the database.
+	MigrationsRef *ConfigMapRef `json:"migrationsRef,omitempty"`
+}
+
+// ConfigMapRef references a ConfigMap by name and namespace.
+type ConfigMapRef struct {
+	Name string `json:"name"`
+	// Namespace defaults to the "default" namespace when empty.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BackupSpec declares scheduled backups of the database.
+type BackupSpec struct {
+	// Schedule is the interval between backups as a Go duration, e.g.
+	// "24h".
+	Schedule string `json:"schedule"`
+	// DestinationURL is the base URL dumps are uploaded to with an HTTP
+	// PUT.
+	DestinationURL string `json:"destinationURL,omitempty"`
+	// DestinationSecretRef references a Secret whose url key holds the
+	// destination URL, keeping credentials embedded in the URL out of the
+	// object.
+	DestinationSecretRef *SecretRef `json:"destinationSecretRef,omitempty"`
+	// Retention is the number of backups kept at the destination. Zero
+	// means backups are never deleted.
+	Retention int `json:"retention,omitempty"`
+}
+
+// UserSpec declares one database user.
+type UserSpec struct {
+	// Name is the user name. The user is created if it doesn't exist.
+	Name string `json:"name"`
+	// Role is one of "owner", "readwrite" or "readonly".
+	Role string `json:"role"`
+	// PasswordSecretRef optionally references a Secret whose password
+	// key is set as the user password.
+	PasswordSecretRef *SecretRef `json:"passwordSecretRef,omitempty"`
+}
+
+// User roles.
+const (
+	RoleOwner     = "owner"
+	RoleReadWrite = "readwrite"
+	RoleReadOnly  = "readonly"
+)
+
+// SecretRef references a Secret by name and namespace.
+type SecretRef struct {
+	Name string `json:"name"`
+	// Namespace defaults to the "default" namespace when empty.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// NamespacePrefixed returns a copy of obj with the database names prefixed
+// by the namespace, so objects in different namespaces declaring the same
+// database name don't collide. The derived name is recorded in the status.
+func NamespacePrefixed(obj *PostgreSQLConfig, namespace string) *PostgreSQLConfig {
+	derived := obj.DeepCopy()
+	derived.Spec.Database = namespace + "_" + obj.Spec.Database
+	if derived.Spec.RenameFrom != "" {
+		derived.Spec.RenameFrom = namespace + "_" + obj.Spec.RenameFrom
+	}
+	derived.Status.DatabaseName = derived.Spec.Database
+	return derived
 }
@@ -0,0 +1,30 @@
+package customobject
+
+// PostgreSQLServer is custom object of postgresqlservers.containerconf.de
+// custom resource. It declares a reachable Postgres instance that
+// PostgreSQLConfig objects can reference by name via Spec.Server.
+type PostgreSQLServer struct {
+	Spec PostgreSQLServerSpec `json:"spec"`
+}
+
+// PostgreSQLServerSpec declares how to reach a Postgres instance.
+type PostgreSQLServerSpec struct {
+	// Host is the server host.
+	Host string `json:"host"`
+	// Port is the server port.
+	Port int `json:"port"`
+	// CredentialsSecret references a Secret holding the user and password
+	// keys.
+	CredentialsSecret SecretRef `json:"credentialsSecret"`
+	// SSLMode is an optional lib/pq sslmode value, e.g. "require".
+	SSLMode string `json:"sslMode,omitempty"`
+	// SSLRootCert is an optional path of the root certificate file used
+	// to verify the server certificate.
+	SSLRootCert string `json:"sslRootCert,omitempty"`
+}
+
+// PostgreSQLServerList represents a list of custom objects. It is useful for
+// decoding list API calls.
+type PostgreSQLServerList struct {
+	Items []*PostgreSQLServer `json:"items"`
+}
@@ -0,0 +1,56 @@
+package customobject
+
+import (
+	"sync"
+
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+)
+
+// ServerRegistry holds the database connections of reconciled
+// PostgreSQLServer objects keyed by object name, so PostgreSQLConfig objects
+// can reference a server by name via Spec.Server.
+type ServerRegistry struct {
+	mutex   sync.Mutex
+	servers map[string]*postgresqlops.PostgreSQLOps
+}
+
+func NewServerRegistry() *ServerRegistry {
+	return &ServerRegistry{
+		servers: map[string]*postgresqlops.PostgreSQLOps{},
+	}
+}
+
+// Register stores the ops under the server name. A replaced connection is
+// closed.
+func (r *ServerRegistry) Register(name string, ops *postgresqlops.PostgreSQLOps) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	old, ok := r.servers[name]
+	if ok && old != ops {
+		old.Close()
+	}
+	r.servers[name] = ops
+}
+
+// Deregister removes and closes the connection of the server name. It is
+// idempotent.
+func (r *ServerRegistry) Deregister(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	old, ok := r.servers[name]
+	if ok {
+		old.Close()
+		delete(r.servers, name)
+	}
+}
+
+// Lookup returns the connection registered under the server name.
+func (r *ServerRegistry) Lookup(name string) (*postgresqlops.PostgreSQLOps, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ops, ok := r.servers[name]
+	return ops, ok
+}
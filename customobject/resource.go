@@ -1,57 +1,510 @@
 package customobject
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
 
 	"github.com/giantswarm/operator-workshop/postgresqlops"
 )
 
+// DatabaseOps is the database access interface the Resource reconciles
+// against. *postgresqlops.PostgreSQLOps implements it, tests use a fake.
+type DatabaseOps interface {
+	Address() (host string, port int)
+	ListDatabases() ([]postgresqlops.Database, error)
+	ListTrashedDatabases() ([]postgresqlops.Database, error)
+	CreateDatabase(name, owner string) error
+	ChangeDatabaseOwner(name, owner string) error
+	RenameDatabase(oldName, newName string) error
+	DeleteDatabase(name string) error
+	DeleteUser(name string) error
+	RevokePublic(name string) error
+	GrantAllOnDatabase(name, user string) error
+	GrantReadOnly(name, user string) error
+	SetConnectionLimit(name string, limit int) error
+	SetUserPassword(user, password string) error
+	RotateUserPassword(ctx context.Context, user string) (string, error)
+	AppliedMigrations(name string) ([]string, error)
+	ApplyMigration(name, migration, migrationSQL string) error
+	DumpDatabase(ctx context.Context, name string, w io.Writer) error
+	RestoreDatabase(ctx context.Context, name string, r io.Reader) error
+}
+
+// OpsResolver resolves a server reference declared in a custom object into a
+// ready PostgreSQLOps. Implementations typically read the referenced Secret.
+type OpsResolver func(ref SecretRef) (*postgresqlops.PostgreSQLOps, error)
+
+// SecretReader reads the data of the referenced Secret. It is used to read
+// declared user passwords.
+type SecretReader func(ref SecretRef) (map[string][]byte, error)
+
+// SecretWriter updates the data of the referenced Secret, merging the given
+// keys. It is used to distribute rotated user passwords.
+type SecretWriter func(ref SecretRef, data map[string][]byte) error
+
+// ConfigMapReader reads the data of the referenced ConfigMap. It is used to
+// read declared SQL migrations.
+type ConfigMapReader func(ref ConfigMapRef) (map[string]string, error)
+
 // Resource represents a resource being a result of PostgreSQLConfig object
 // reconciliation. In this case it is a database with owner set to a specified
 // user.
 type Resource struct {
-	ops *postgresqlops.PostgreSQLOps
+	ops             DatabaseOps
+	resolver        OpsResolver
+	registry        *ServerRegistry
+	secretReader    SecretReader
+	secretWriter    SecretWriter
+	configMapReader ConfigMapReader
+
+	// mutex guards cache holding resolved connections keyed by server
+	// reference, so objects targeting the same server share a connection.
+	mutex sync.Mutex
+	cache map[string]DatabaseOps
 }
 
-func NewResource(ops *postgresqlops.PostgreSQLOps) *Resource {
+func NewResource(ops DatabaseOps) *Resource {
 	return &Resource{
-		ops: ops,
+		ops:   ops,
+		cache: map[string]DatabaseOps{},
+	}
+}
+
+// NewResourceWithResolver creates a Resource additionally able to reconcile
+// objects declaring Spec.ServerRef. The default ops is still used for
+// objects without a server reference.
+func NewResourceWithResolver(ops DatabaseOps, resolver OpsResolver) *Resource {
+	r := NewResource(ops)
+	r.resolver = resolver
+	return r
+}
+
+// SetServerRegistry lets the Resource reconcile objects referencing a
+// PostgreSQLServer object by name via Spec.Server.
+func (r *Resource) SetServerRegistry(registry *ServerRegistry) {
+	r.registry = registry
+}
+
+// SetSecretReader lets the Resource set passwords of users declaring
+// PasswordSecretRef.
+func (r *Resource) SetSecretReader(reader SecretReader) {
+	r.secretReader = reader
+}
+
+// SetSecretWriter lets the Resource rotate passwords of users declaring
+// PasswordSecretRef, see RotateUserPasswords.
+func (r *Resource) SetSecretWriter(writer SecretWriter) {
+	r.secretWriter = writer
+}
+
+// SetConfigMapReader lets the Resource apply migrations of objects declaring
+// MigrationsRef.
+func (r *Resource) SetConfigMapReader(reader ConfigMapReader) {
+	r.configMapReader = reader
+}
+
+// opsFor returns the PostgreSQLOps for the server the custom object targets.
+// Resolved connections are cached so consecutive reconciliations reuse them.
+func (r *Resource) opsFor(obj *PostgreSQLConfig) (DatabaseOps, error) {
+	if obj.Spec.Server != "" {
+		if r.registry == nil {
+			return nil, fmt.Errorf("looking up server=%#q: no server registry configured", obj.Spec.Server)
+		}
+		ops, ok := r.registry.Lookup(obj.Spec.Server)
+		if !ok {
+			return nil, fmt.Errorf("looking up server=%#q: no such PostgreSQLServer object", obj.Spec.Server)
+		}
+		return ops, nil
+	}
+
+	if obj.Spec.ServerRef == nil {
+		return r.ops, nil
+	}
+	if r.resolver == nil {
+		return nil, fmt.Errorf("resolving server ref name=%#q: no resolver configured", obj.Spec.ServerRef.Name)
+	}
+
+	key := obj.Spec.ServerRef.Namespace + "/" + obj.Spec.ServerRef.Name
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ops, ok := r.cache[key]
+	if ok {
+		return ops, nil
+	}
+
+	ops, err := r.resolver(*obj.Spec.ServerRef)
+	if err != nil {
+		return nil, fmt.Errorf("resolving server ref name=%#q: %s", obj.Spec.ServerRef.Name, err)
 	}
+	r.cache[key] = ops
+
+	return ops, nil
 }
 
 // EnsureCreated is an idempotent method making sure the database resource is
 // in a state described in the custom object.
 func (r *Resource) EnsureCreated(obj *PostgreSQLConfig) (status string, err error) {
-	dbs, err := r.ops.ListDatabases()
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return "", err
+	}
+
+	dbs, err := ops.ListDatabases()
 	if err != nil {
 		return "", fmt.Errorf("listing databases: %s", err)
 	}
 
 	db, ok := findDB(dbs, obj.Spec.Database)
 
+	// Detect a rename. When the object declares its previous database name
+	// and the database under the new name doesn't exist yet, rename
+	// instead of creating a second database and orphaning the old one.
+	if !ok && obj.Spec.RenameFrom != "" {
+		_, oldOK := findDB(dbs, obj.Spec.RenameFrom)
+		if oldOK {
+			err := ops.RenameDatabase(obj.Spec.RenameFrom, obj.Spec.Database)
+			if err != nil {
+				return "", fmt.Errorf("renaming database from=%#q: %s", obj.Spec.RenameFrom, err)
+			}
+			err = r.ensureSettings(ops, obj, db, false)
+			if err != nil {
+				return "", err
+			}
+			err = r.ensureMigrations(ops, obj)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("database renamed from=%#q", obj.Spec.RenameFrom), nil
+		}
+	}
+
 	if !ok {
-		err := r.ops.CreateDatabase(obj.Spec.Database, obj.Spec.Owner)
+		err := ops.CreateDatabase(obj.Spec.Database, obj.Spec.Owner)
+		if postgresqlops.IsQuotaExceeded(err) {
+			// Refuse the creation without returning an error. Retrying
+			// cannot help until the quota or the databases change.
+			return fmt.Sprintf("refused: %s", err), nil
+		}
 		if err != nil {
 			return "", fmt.Errorf("creating database: %s", err)
 		}
+		err = r.ensureSettings(ops, obj, db, false)
+		if err != nil {
+			return "", err
+		}
+		err = r.restoreInitialDump(ops, obj)
+		if err != nil {
+			return "", err
+		}
+		err = r.ensureMigrations(ops, obj)
+		if err != nil {
+			return "", err
+		}
 		return "database created", nil
 	}
 
 	if db.Owner != obj.Spec.Owner {
-		err := r.ops.ChangeDatabaseOwner(obj.Spec.Database, obj.Spec.Owner)
+		err := ops.ChangeDatabaseOwner(obj.Spec.Database, obj.Spec.Owner)
 		if err != nil {
 			return "", fmt.Errorf("chaning owner=%#q: %s", db.Owner, err)
 		}
+		err = r.ensureSettings(ops, obj, db, true)
+		if err != nil {
+			return "", err
+		}
+		err = r.ensureMigrations(ops, obj)
+		if err != nil {
+			return "", err
+		}
 		return fmt.Sprintf("owner=%#q changed", db.Owner), nil
 	}
 
+	err = r.ensureSettings(ops, obj, db, true)
+	if err != nil {
+		return "", err
+	}
+
+	err = r.ensureMigrations(ops, obj)
+	if err != nil {
+		return "", err
+	}
+
 	return "already created", nil
 }
 
+// ensureMigrations applies the unapplied migrations from the referenced
+// ConfigMap inside the database. ConfigMap keys are applied in lexical
+// order, the bookkeeping table inside the database records what was applied.
+func (r *Resource) ensureMigrations(ops DatabaseOps, obj *PostgreSQLConfig) error {
+	if obj.Spec.MigrationsRef == nil {
+		return nil
+	}
+	if r.configMapReader == nil {
+		return fmt.Errorf("applying migrations: no config map reader configured")
+	}
+
+	data, err := r.configMapReader(*obj.Spec.MigrationsRef)
+	if err != nil {
+		return fmt.Errorf("applying migrations: %s", err)
+	}
+
+	var names []string
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	applied, err := ops.AppliedMigrations(obj.Spec.Database)
+	if err != nil {
+		return fmt.Errorf("applying migrations: %s", err)
+	}
+
+	appliedSet := map[string]bool{}
+	for _, name := range applied {
+		appliedSet[name] = true
+	}
+
+	for _, name := range names {
+		if appliedSet[name] {
+			continue
+		}
+		err := ops.ApplyMigration(obj.Spec.Database, name, data[name])
+		if err != nil {
+			return fmt.Errorf("applying migrations: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// AppliedMigrations returns the migrations recorded as applied in the
+// object's database. It is recorded in the object status for visibility.
+func (r *Resource) AppliedMigrations(obj *PostgreSQLConfig) ([]string, error) {
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return ops.AppliedMigrations(obj.Spec.Database)
+}
+
+// Connection reports how the provisioned database is connected to, resolving
+// the server the same way reconciliation does. It is recorded in the object
+// status for consumers.
+func (r *Resource) Connection(obj *PostgreSQLConfig) (*ConnectionStatus, error) {
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	host, port := ops.Address()
+
+	connection := &ConnectionStatus{
+		Host:     host,
+		Port:     port,
+		Database: obj.Spec.Database,
+	}
+	if obj.Spec.ServerRef != nil {
+		ref := *obj.Spec.ServerRef
+		connection.SecretRef = &ref
+	}
+
+	return connection, nil
+}
+
+// DumpDatabase writes a plain-format dump of the object's database to the
+// writer, resolving the server the same way reconciliation does.
+func (r *Resource) DumpDatabase(ctx context.Context, obj *PostgreSQLConfig, w io.Writer) error {
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return err
+	}
+
+	err = ops.DumpDatabase(ctx, obj.Spec.Database, w)
+	if err != nil {
+		return fmt.Errorf("dumping database: %s", err)
+	}
+
+	return nil
+}
+
+// restoreInitialDump seeds a freshly created database from the dump URL
+// declared in the custom object. Nothing happens when no URL is set.
+func (r *Resource) restoreInitialDump(ops DatabaseOps, obj *PostgreSQLConfig) error {
+	if obj.Spec.InitialDumpURL == "" {
+		return nil
+	}
+
+	res, err := http.Get(obj.Spec.InitialDumpURL)
+	if err != nil {
+		return fmt.Errorf("fetching initial dump url=%#q: %s", obj.Spec.InitialDumpURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching initial dump url=%#q: bad status status=%d", obj.Spec.InitialDumpURL, res.StatusCode)
+	}
+
+	err = ops.RestoreDatabase(context.Background(), obj.Spec.Database, res.Body)
+	if err != nil {
+		return fmt.Errorf("restoring initial dump: %s", err)
+	}
+
+	return nil
+}
+
+// ensureSettings reconciles the database settings that can drift
+// independently of existence and ownership, i.e. privileges and the
+// connection limit. With known set the current database state is used to
+// skip no-op statements.
+func (r *Resource) ensureSettings(ops DatabaseOps, obj *PostgreSQLConfig, db postgresqlops.Database, known bool) error {
+	err := r.ensureGrants(ops, obj)
+	if err != nil {
+		return err
+	}
+
+	if obj.Spec.ConnectionLimit != 0 {
+		if !known || db.ConnLimit != obj.Spec.ConnectionLimit {
+			err := ops.SetConnectionLimit(obj.Spec.Database, obj.Spec.ConnectionLimit)
+			if err != nil {
+				return fmt.Errorf("setting connection limit=%d: %s", obj.Spec.ConnectionLimit, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensureGrants is an idempotent method reconciling privileges on the database.
+// PUBLIC access is always revoked on managed databases. The owner and the
+// optional read-write user get all privileges, the optional read-only user
+// gets connect privileges.
+func (r *Resource) ensureGrants(ops DatabaseOps, obj *PostgreSQLConfig) error {
+	err := ops.RevokePublic(obj.Spec.Database)
+	if err != nil {
+		return fmt.Errorf("revoking public privileges: %s", err)
+	}
+
+	err = ops.GrantAllOnDatabase(obj.Spec.Database, obj.Spec.Owner)
+	if err != nil {
+		return fmt.Errorf("granting privileges to owner=%#q: %s", obj.Spec.Owner, err)
+	}
+
+	if obj.Spec.ReadWriteUser != "" {
+		err := ops.GrantAllOnDatabase(obj.Spec.Database, obj.Spec.ReadWriteUser)
+		if err != nil {
+			return fmt.Errorf("granting privileges to user=%#q: %s", obj.Spec.ReadWriteUser, err)
+		}
+	}
+
+	if obj.Spec.ReadOnlyUser != "" {
+		err := ops.GrantReadOnly(obj.Spec.Database, obj.Spec.ReadOnlyUser)
+		if err != nil {
+			return fmt.Errorf("granting connect privileges to user=%#q: %s", obj.Spec.ReadOnlyUser, err)
+		}
+	}
+
+	for _, user := range obj.Spec.Users {
+		switch user.Role {
+		case RoleOwner, RoleReadWrite:
+			err := ops.GrantAllOnDatabase(obj.Spec.Database, user.Name)
+			if err != nil {
+				return fmt.Errorf("granting privileges to user=%#q: %s", user.Name, err)
+			}
+		case RoleReadOnly:
+			err := ops.GrantReadOnly(obj.Spec.Database, user.Name)
+			if err != nil {
+				return fmt.Errorf("granting connect privileges to user=%#q: %s", user.Name, err)
+			}
+		default:
+			return fmt.Errorf("granting privileges to user=%#q: unknown role=%#q", user.Name, user.Role)
+		}
+
+		err := r.ensureUserPassword(ops, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureUserPassword sets the user password from the referenced Secret.
+// Nothing happens when the user declares no password Secret.
+func (r *Resource) ensureUserPassword(ops DatabaseOps, user UserSpec) error {
+	if user.PasswordSecretRef == nil {
+		return nil
+	}
+	if r.secretReader == nil {
+		return fmt.Errorf("setting password of user=%#q: no secret reader configured", user.Name)
+	}
+
+	data, err := r.secretReader(*user.PasswordSecretRef)
+	if err != nil {
+		return fmt.Errorf("setting password of user=%#q: %s", user.Name, err)
+	}
+
+	password, ok := data["password"]
+	if !ok {
+		return fmt.Errorf("setting password of user=%#q: secret name=%#q misses key=%#q", user.Name, user.PasswordSecretRef.Name, "password")
+	}
+
+	err = ops.SetUserPassword(user.Name, string(password))
+	if err != nil {
+		return fmt.Errorf("setting password of user=%#q: %s", user.Name, err)
+	}
+
+	return nil
+}
+
+// RotateUserPasswords generates new passwords for all users declaring a
+// password Secret and writes them back to the referenced Secrets, so
+// applications pick up the rotated credentials. It is triggered by
+// RotateUsersAnnotation.
+func (r *Resource) RotateUserPasswords(ctx context.Context, obj *PostgreSQLConfig) error {
+	if r.secretWriter == nil {
+		return fmt.Errorf("rotating user passwords: no secret writer configured")
+	}
+
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range obj.Spec.Users {
+		if user.PasswordSecretRef == nil {
+			continue
+		}
+
+		password, err := ops.RotateUserPassword(ctx, user.Name)
+		if err != nil {
+			return fmt.Errorf("rotating password of user=%#q: %s", user.Name, err)
+		}
+
+		err = r.secretWriter(*user.PasswordSecretRef, map[string][]byte{"password": []byte(password)})
+		if err != nil {
+			return fmt.Errorf("rotating password of user=%#q: updating secret name=%#q: %s", user.Name, user.PasswordSecretRef.Name, err)
+		}
+	}
+
+	return nil
+}
+
 // EnsureCreated is an idempotent method making sure the database resource
 // described in the custom object is deleted.
 func (r *Resource) EnsureDeleted(obj *PostgreSQLConfig) (status string, err error) {
-	dbs, err := r.ops.ListDatabases()
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return "", err
+	}
+
+	dbs, err := ops.ListDatabases()
 	if err != nil {
 		return "", fmt.Errorf("listing databases: %s", err)
 	}
@@ -59,16 +512,76 @@ func (r *Resource) EnsureDeleted(obj *PostgreSQLConfig) (status string, err erro
 	_, ok := findDB(dbs, obj.Spec.Database)
 
 	if ok {
-		err = r.ops.DeleteDatabase(obj.Spec.Database)
+		err = ops.DeleteDatabase(obj.Spec.Database)
 		if err != nil {
 			return "", fmt.Errorf("deleting database: %s", err)
 		}
+		err = r.deleteOrphanOwner(ops, obj.Spec.Owner)
+		if err != nil {
+			return "", err
+		}
 		return "database deleted", nil
 	}
 
 	return "already deleted", nil
 }
 
+// CurrentDatabase returns the database described in the custom object as it
+// currently exists on the target server, or nil when it doesn't exist.
+func (r *Resource) CurrentDatabase(obj *PostgreSQLConfig) (*postgresqlops.Database, error) {
+	ops, err := r.opsFor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	dbs, err := ops.ListDatabases()
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %s", err)
+	}
+
+	db, ok := findDB(dbs, obj.Spec.Database)
+	if !ok {
+		return nil, nil
+	}
+
+	return &db, nil
+}
+
+// deleteOrphanOwner deletes the owner role when no remaining database
+// references it, so deleted custom objects don't leave orphan roles behind.
+// Databases trashed by the soft-delete mode still belong to their owner, so
+// the role is kept until they are purged.
+func (r *Resource) deleteOrphanOwner(ops DatabaseOps, owner string) error {
+	dbs, err := ops.ListDatabases()
+	if err != nil {
+		return fmt.Errorf("listing databases: %s", err)
+	}
+
+	for _, db := range dbs {
+		if db.Owner == owner {
+			return nil
+		}
+	}
+
+	trashed, err := ops.ListTrashedDatabases()
+	if err != nil {
+		return fmt.Errorf("listing trashed databases: %s", err)
+	}
+
+	for _, db := range trashed {
+		if db.Owner == owner {
+			return nil
+		}
+	}
+
+	err = ops.DeleteUser(owner)
+	if err != nil {
+		return fmt.Errorf("deleting orphan owner=%#q: %s", owner, err)
+	}
+
+	return nil
+}
+
 func findDB(dbs []postgresqlops.Database, name string) (postgresqlops.Database, bool) {
 	for _, db := range dbs {
 		if db.Name == name {
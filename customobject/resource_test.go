@@ -0,0 +1,251 @@
+package customobject
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+)
+
+func TestResourceEnsureCreated(t *testing.T) {
+	tests := []struct {
+		name string
+
+		obj       *PostgreSQLConfig
+		databases []postgresqlops.Database
+		errs      map[string]error
+
+		expectedStatus string
+		expectedErr    bool
+		expectedCalls  []string
+		forbiddenCalls []string
+	}{
+		{
+			name: "create database when it does not exist",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			expectedStatus: "database created",
+			expectedCalls:  []string{"CreateDatabase(db,owner)", "RevokePublic(db)", "GrantAllOnDatabase(db,owner)"},
+		},
+		{
+			name: "change owner when it drifted",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "other"}},
+			expectedStatus: "owner=`other` changed",
+			expectedCalls:  []string{"ChangeDatabaseOwner(db,owner)"},
+			forbiddenCalls: []string{"CreateDatabase("},
+		},
+		{
+			name: "idempotent re-apply",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "owner"}},
+			expectedStatus: "already created",
+			forbiddenCalls: []string{"CreateDatabase(", "ChangeDatabaseOwner("},
+		},
+		{
+			name: "rename declared previous database",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner", RenameFrom: "olddb"},
+			},
+			databases:      []postgresqlops.Database{{Name: "olddb", Owner: "owner"}},
+			expectedStatus: "database renamed from=`olddb`",
+			expectedCalls:  []string{"RenameDatabase(olddb,db)"},
+			forbiddenCalls: []string{"CreateDatabase("},
+		},
+		{
+			name: "grant declared users",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{
+					Database: "db",
+					Owner:    "owner",
+					Users: []UserSpec{
+						{Name: "writer", Role: RoleReadWrite},
+						{Name: "reader", Role: RoleReadOnly},
+					},
+				},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "owner"}},
+			expectedStatus: "already created",
+			expectedCalls:  []string{"GrantAllOnDatabase(db,writer)", "GrantReadOnly(db,reader)"},
+		},
+		{
+			name: "set drifted connection limit",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner", ConnectionLimit: 5},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "owner", ConnLimit: 10}},
+			expectedStatus: "already created",
+			expectedCalls:  []string{"SetConnectionLimit(db,5)"},
+		},
+		{
+			name: "skip matching connection limit",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner", ConnectionLimit: 5},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "owner", ConnLimit: 5}},
+			expectedStatus: "already created",
+			forbiddenCalls: []string{"SetConnectionLimit("},
+		},
+		{
+			name: "listing error",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			errs:        map[string]error{"ListDatabases": fmt.Errorf("connection refused")},
+			expectedErr: true,
+		},
+		{
+			name: "creation error",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			errs:        map[string]error{"CreateDatabase": fmt.Errorf("connection refused")},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &FakeOps{
+				Databases: tc.databases,
+				Errs:      tc.errs,
+			}
+			resource := NewResource(fake)
+
+			status, err := resource.EnsureCreated(tc.obj)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got status=%#q", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if status != tc.expectedStatus {
+				t.Errorf("expected status=%#q, got status=%#q", tc.expectedStatus, status)
+			}
+			for _, call := range tc.expectedCalls {
+				if !fake.Called(call) {
+					t.Errorf("expected call %#q, got calls %v", call, fake.Calls)
+				}
+			}
+			for _, call := range tc.forbiddenCalls {
+				if fake.Called(call) {
+					t.Errorf("forbidden call %#q, got calls %v", call, fake.Calls)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceEnsureDeleted(t *testing.T) {
+	tests := []struct {
+		name string
+
+		obj       *PostgreSQLConfig
+		databases []postgresqlops.Database
+		trashed   []postgresqlops.Database
+		errs      map[string]error
+
+		expectedStatus string
+		expectedErr    bool
+		expectedCalls  []string
+		forbiddenCalls []string
+	}{
+		{
+			name: "delete database and orphan owner",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases:      []postgresqlops.Database{{Name: "db", Owner: "owner"}},
+			expectedStatus: "database deleted",
+			expectedCalls:  []string{"DeleteDatabase(db)", "DeleteUser(owner)"},
+		},
+		{
+			name: "keep owner referenced by another database",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases: []postgresqlops.Database{
+				{Name: "db", Owner: "owner"},
+				{Name: "otherdb", Owner: "owner"},
+			},
+			expectedStatus: "database deleted",
+			expectedCalls:  []string{"DeleteDatabase(db)"},
+			forbiddenCalls: []string{"DeleteUser("},
+		},
+		{
+			name: "keep owner referenced by trashed database",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases: []postgresqlops.Database{
+				{Name: "db", Owner: "owner"},
+			},
+			trashed: []postgresqlops.Database{
+				{Name: "olddb_deleted_20180101120000", Owner: "owner"},
+			},
+			expectedStatus: "database deleted",
+			expectedCalls:  []string{"DeleteDatabase(db)"},
+			forbiddenCalls: []string{"DeleteUser("},
+		},
+		{
+			name: "already deleted",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			expectedStatus: "already deleted",
+			forbiddenCalls: []string{"DeleteDatabase(", "DeleteUser("},
+		},
+		{
+			name: "deletion error",
+			obj: &PostgreSQLConfig{
+				Spec: PostgreSQLConfigSpec{Database: "db", Owner: "owner"},
+			},
+			databases:   []postgresqlops.Database{{Name: "db", Owner: "owner"}},
+			errs:        map[string]error{"DeleteDatabase": fmt.Errorf("connection refused")},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &FakeOps{
+				Databases: tc.databases,
+				Trashed:   tc.trashed,
+				Errs:      tc.errs,
+			}
+			resource := NewResource(fake)
+
+			status, err := resource.EnsureDeleted(tc.obj)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got status=%#q", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if status != tc.expectedStatus {
+				t.Errorf("expected status=%#q, got status=%#q", tc.expectedStatus, status)
+			}
+			for _, call := range tc.expectedCalls {
+				if !fake.Called(call) {
+					t.Errorf("expected call %#q, got calls %v", call, fake.Calls)
+				}
+			}
+			for _, call := range tc.forbiddenCalls {
+				if fake.Called(call) {
+					t.Errorf("forbidden call %#q, got calls %v", call, fake.Calls)
+				}
+			}
+		})
+	}
+}
@@ -1,15 +1,160 @@
 package customobject
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+// FieldError describes a validation failure of a single field, so callers
+// like the webhook and status conditions can surface the failing field.
+type FieldError struct {
+	// Field is the JSON path of the failing field, e.g. "spec.database".
+	Field string
+	// Reason explains the failure.
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// IsFieldError asserts *FieldError.
+func IsFieldError(err error) bool {
+	_, ok := err.(*FieldError)
+	return ok
+}
+
+// reservedNames are database names managed by Postgres itself, which the
+// operator refuses to touch.
+var reservedNames = map[string]bool{
+	"postgres":  true,
+	"template0": true,
+	"template1": true,
+}
 
 func Validate(obj PostgreSQLConfig) error {
 	if err := validateSpec(obj.Spec); err != nil {
-		return fmt.Errorf("spec is not valid: %s", err)
+		return err
 	}
 	return nil
 }
 
 func validateSpec(spec PostgreSQLConfigSpec) error {
+	if spec.Database == "" {
+		return &FieldError{Field: "spec.database", Reason: "is not set"}
+	}
+	if err := validateIdentifier("spec.database", spec.Database); err != nil {
+		return err
+	}
+	if spec.Owner == "" {
+		return &FieldError{Field: "spec.owner", Reason: "is not set"}
+	}
+	if err := validateIdentifier("spec.owner", spec.Owner); err != nil {
+		return err
+	}
+	if spec.ReadWriteUser != "" {
+		if err := validateIdentifier("spec.readWriteUser", spec.ReadWriteUser); err != nil {
+			return err
+		}
+	}
+	if spec.ReadOnlyUser != "" {
+		if err := validateIdentifier("spec.readOnlyUser", spec.ReadOnlyUser); err != nil {
+			return err
+		}
+	}
+	if spec.RenameFrom != "" {
+		if err := validateIdentifier("spec.renameFrom", spec.RenameFrom); err != nil {
+			return err
+		}
+	}
+	for i, user := range spec.Users {
+		field := fmt.Sprintf("spec.users[%d]", i)
+		if user.Name == "" {
+			return &FieldError{Field: field + ".name", Reason: "is not set"}
+		}
+		if err := validateIdentifier(field+".name", user.Name); err != nil {
+			return err
+		}
+		switch user.Role {
+		case RoleOwner, RoleReadWrite, RoleReadOnly:
+		default:
+			return &FieldError{Field: field + ".role", Reason: fmt.Sprintf("role=%#q is not valid", user.Role)}
+		}
+	}
+	if spec.MigrationsRef != nil && spec.MigrationsRef.Name == "" {
+		return &FieldError{Field: "spec.migrationsRef.name", Reason: "is not set"}
+	}
+	if spec.Backup != nil {
+		if spec.Backup.Schedule == "" {
+			return &FieldError{Field: "spec.backup.schedule", Reason: "is not set"}
+		}
+		if _, err := time.ParseDuration(spec.Backup.Schedule); err != nil {
+			return &FieldError{Field: "spec.backup.schedule", Reason: fmt.Sprintf("schedule=%#q is not a valid duration", spec.Backup.Schedule)}
+		}
+		if spec.Backup.DestinationURL == "" && spec.Backup.DestinationSecretRef == nil {
+			return &FieldError{Field: "spec.backup", Reason: "destination is not set"}
+		}
+	}
+	return nil
+}
+
+// validateIdentifier enforces Postgres identifier rules: at most 63 bytes,
+// lower-case letters, digits, underscores and dollar signs, not starting
+// with a digit or dollar sign, and not a name reserved by Postgres. Upper
+// case is rejected instead of silently folded, Postgres would fold unquoted
+// identifiers anyway and the operator quotes them.
+func validateIdentifier(field, name string) error {
+	if len(name) > 63 {
+		return &FieldError{Field: field, Reason: fmt.Sprintf("name=%#q is longer than 63 bytes", name)}
+	}
+	if reservedNames[name] {
+		return &FieldError{Field: field, Reason: fmt.Sprintf("name=%#q is reserved", name)}
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c == '_':
+		case c >= '0' && c <= '9' || c == '$':
+			if i == 0 {
+				return &FieldError{Field: field, Reason: fmt.Sprintf("name=%#q must not start with %q", name, c)}
+			}
+		case c >= 'A' && c <= 'Z':
+			return &FieldError{Field: field, Reason: fmt.Sprintf("name=%#q must be lower case", name)}
+		default:
+			return &FieldError{Field: field, Reason: fmt.Sprintf("name=%#q contains invalid character %q", name, c)}
+		}
+	}
+	return nil
+}
+
+func ValidatePostgreSQLServer(obj PostgreSQLServer) error {
+	if err := validateServerSpec(obj.Spec); err != nil {
+		return fmt.Errorf("spec is not valid: %s", err)
+	}
+	return nil
+}
+
+func validateServerSpec(spec PostgreSQLServerSpec) error {
+	if spec.Host == "" {
+		return fmt.Errorf("host is not set")
+	}
+	if spec.Port == 0 {
+		return fmt.Errorf("port is not set")
+	}
+	if spec.CredentialsSecret.Name == "" {
+		return fmt.Errorf("credentials secret name is not set")
+	}
+	return nil
+}
+
+func ValidateMySQL(obj MySQLConfig) error {
+	if err := validateMySQLSpec(obj.Spec); err != nil {
+		return fmt.Errorf("spec is not valid: %s", err)
+	}
+	return nil
+}
+
+func validateMySQLSpec(spec MySQLConfigSpec) error {
 	if spec.Database == "" {
 		return fmt.Errorf("database is not set")
 	}
@@ -0,0 +1,238 @@
+// Package discovery resolves the address of a Kubernetes Service through
+// the Kubernetes API. It replaces shelling out to the minikube binary so
+// the operators also work against kind, k3d and real clusters.
+package discovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ModeAuto resolves through the node port when the Service has one
+	// and falls back to a port-forward otherwise.
+	ModeAuto = "auto"
+	// ModeClusterIP resolves to the cluster IP of the Service. It only
+	// works from inside the cluster.
+	ModeClusterIP = "clusterip"
+	// ModeNodePort resolves to a node address and the node port of the
+	// Service.
+	ModeNodePort = "nodeport"
+	// ModePortForward forwards a local port to a Pod backing the Service
+	// and resolves to the local listener. The forward stays open for the
+	// process lifetime.
+	ModePortForward = "port-forward"
+)
+
+type Config struct {
+	// RestConfig is the Kubernetes client configuration used to look up
+	// the Service and to establish port-forwards.
+	RestConfig *rest.Config
+	// Namespace and Service identify the Service to resolve.
+	Namespace string
+	Service   string
+	// Mode selects the resolution strategy. See the Mode constants.
+	// Empty means ModeAuto.
+	Mode string
+}
+
+// Resolve returns a host and port connecting to the Service identified in
+// the configuration.
+func Resolve(config Config) (string, int, error) {
+	k8sClient, err := kubernetes.NewForConfig(config.RestConfig)
+	if err != nil {
+		return "", 0, fmt.Errorf("creating K8s client: %s", err)
+	}
+
+	service, err := k8sClient.CoreV1().Services(config.Namespace).Get(config.Service, apismetav1.GetOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("getting service %#q: %s", config.Service, err)
+	}
+	if len(service.Spec.Ports) == 0 {
+		return "", 0, fmt.Errorf("service %#q has no ports", config.Service)
+	}
+	port := service.Spec.Ports[0]
+
+	mode := config.Mode
+	if mode == "" || mode == ModeAuto {
+		if port.NodePort != 0 {
+			mode = ModeNodePort
+		} else {
+			mode = ModePortForward
+		}
+	}
+
+	switch mode {
+	case ModeClusterIP:
+		return service.Spec.ClusterIP, int(port.Port), nil
+	case ModeNodePort:
+		if port.NodePort == 0 {
+			return "", 0, fmt.Errorf("service %#q has no node port", config.Service)
+		}
+		host, err := nodeAddress(k8sClient)
+		if err != nil {
+			return "", 0, err
+		}
+		return host, int(port.NodePort), nil
+	case ModePortForward:
+		return portForwardService(config, k8sClient, service)
+	}
+
+	return "", 0, fmt.Errorf("unknown discovery mode %#q", config.Mode)
+}
+
+// nodeAddress returns an address of a cluster node, preferring external IPs
+// over internal ones.
+func nodeAddress(k8sClient *kubernetes.Clientset) (string, error) {
+	nodes, err := k8sClient.CoreV1().Nodes().List(apismetav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("listing nodes: %s", err)
+	}
+
+	var internal string
+	for _, node := range nodes.Items {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == v1.NodeExternalIP && addr.Address != "" {
+				return addr.Address, nil
+			}
+			if addr.Type == v1.NodeInternalIP && internal == "" {
+				internal = addr.Address
+			}
+		}
+	}
+	if internal != "" {
+		return internal, nil
+	}
+
+	return "", fmt.Errorf("no node with an address found")
+}
+
+// portForwardService forwards a local port to a running Pod backing the
+// Service and returns the local listener address. The forward stays open
+// for the process lifetime.
+func portForwardService(config Config, k8sClient *kubernetes.Clientset, service *v1.Service) (string, int, error) {
+	pod, err := backingPod(k8sClient, service)
+	if err != nil {
+		return "", 0, err
+	}
+
+	remotePort, err := podPort(service, pod)
+	if err != nil {
+		return "", 0, err
+	}
+
+	localPort, err := freePort()
+	if err != nil {
+		return "", 0, err
+	}
+
+	req := k8sClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+
+	dialer, err := remotecommand.NewExecutor(config.RestConfig, "POST", req.URL())
+	if err != nil {
+		return "", 0, fmt.Errorf("creating port-forward dialer: %s", err)
+	}
+
+	// The forward stays open for the process lifetime so the stop channel
+	// is never closed.
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	fw, err := portforward.New(dialer, ports, stopCh, readyCh, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return "", 0, fmt.Errorf("creating port-forward: %s", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		err := fw.ForwardPorts()
+		if err != nil {
+			logging.Errorf("port-forward pod=%s: %s", pod.Name, err)
+		}
+		errCh <- err
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return "", 0, fmt.Errorf("establishing port-forward to pod %#q: %s", pod.Name, err)
+	}
+
+	logging.Infof("port-forward pod=%s: forwarding 127.0.0.1:%d to %d", pod.Name, localPort, remotePort)
+	return "127.0.0.1", localPort, nil
+}
+
+// backingPod returns a running Pod matched by the Service selector.
+func backingPod(k8sClient *kubernetes.Clientset, service *v1.Service) (*v1.Pod, error) {
+	if len(service.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %#q has no selector", service.Name)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set(service.Spec.Selector)).String()
+	pods, err := k8sClient.CoreV1().Pods(service.Namespace).List(apismetav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods selector=%s: %s", selector, err)
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == v1.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no running pod matching selector=%s found", selector)
+}
+
+// podPort resolves the Service target port against the Pod's container
+// ports.
+func podPort(service *v1.Service, pod *v1.Pod) (int, error) {
+	target := service.Spec.Ports[0].TargetPort
+	switch target.Type {
+	case intstr.Int:
+		if target.IntValue() != 0 {
+			return target.IntValue(), nil
+		}
+		return int(service.Spec.Ports[0].Port), nil
+	case intstr.String:
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == target.StrVal {
+					return int(p.ContainerPort), nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("no container port named %#q in pod %#q", target.StrVal, pod.Name)
+	}
+
+	return 0, fmt.Errorf("unknown target port type %d", target.Type)
+}
+
+// freePort asks the kernel for a free TCP port. There is an inherent race
+// until the port-forward listener binds it again, which is acceptable at
+// startup.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("finding free port: %s", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,95 @@
+// Package eventrecorder posts Kubernetes Events for reconciliation outcomes
+// so they show up in kubectl describe on the custom objects instead of only
+// in operator logs.
+package eventrecorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"k8s.io/client-go/rest"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiv1 "k8s.io/client-go/pkg/api/v1"
+)
+
+// Config is the event recorder configuration.
+type Config struct {
+	// RESTClient is any Kubernetes REST client. Events are posted with
+	// absolute paths so the client's group doesn't matter.
+	RESTClient rest.Interface
+	// Component is reported as the event source.
+	Component string
+	// DryRun logs the would-be events instead of posting them.
+	DryRun bool
+}
+
+// Recorder posts Events involving custom objects.
+type Recorder struct {
+	restClient rest.Interface
+	component  string
+	dryRun     bool
+}
+
+// New creates an event recorder.
+func New(config Config) *Recorder {
+	return &Recorder{
+		restClient: config.RESTClient,
+		component:  config.Component,
+		dryRun:     config.DryRun,
+	}
+}
+
+// Eventf posts an Event involving the object identified by kind, namespace
+// and name. Event posting is best effort. Failures are logged and never
+// interrupt reconciliation.
+func (r *Recorder) Eventf(kind, namespace, name, eventType, reason, messageFmt string, args ...interface{}) {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	if r.dryRun {
+		logging.Infof("dry-run: would record event kind=%s namespace=%s name=%s type=%s reason=%s message=%#q", kind, namespace, name, eventType, reason, fmt.Sprintf(messageFmt, args...))
+		return
+	}
+
+	now := apismetav1.Time{Time: time.Now().UTC()}
+
+	event := apiv1.Event{
+		TypeMeta: apismetav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Event",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      fmt.Sprintf("%s.%d", name, now.UnixNano()),
+		},
+		InvolvedObject: apiv1.ObjectReference{
+			APIVersion: "containerconf.de/v1",
+			Kind:       kind,
+			Namespace:  namespace,
+			Name:       name,
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        fmt.Sprintf(messageFmt, args...),
+		Source:         apiv1.EventSource{Component: r.component},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logging.Warnf("recording event: marshalling: %s", err)
+		return
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/events", namespace)
+	err = r.restClient.Post().AbsPath(endpoint).Body(body).Do().Error()
+	if err != nil {
+		logging.Warnf("recording event: requesting endpoint=%s: %s", endpoint, err)
+	}
+}
@@ -0,0 +1,283 @@
+// Package leaderelect implements leader election on top of an annotated
+// ConfigMap lock, so multiple operator replicas can run for availability
+// while only the leader reconciles. The vendored client libraries predate
+// the coordination Lease API, the ConfigMap lock follows the same protocol
+// the Kubernetes control plane components use.
+package leaderelect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"k8s.io/client-go/rest"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// leaderAnnotation holds the leader election record on the lock ConfigMap.
+// It is the same annotation the Kubernetes control plane components use.
+const leaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+// Config is the elector configuration.
+type Config struct {
+	// RESTClient is any Kubernetes REST client. The lock is accessed with
+	// absolute paths so the client's group doesn't matter.
+	RESTClient rest.Interface
+
+	// Namespace and Name identify the lock ConfigMap. They default to
+	// "default" and "operator-workshop-leader".
+	Namespace string
+	Name      string
+	// Identity is this candidate's identity, e.g. the pod name. It must
+	// be unique per replica.
+	Identity string
+	// LeaseDuration is how long a leadership is valid without renewal.
+	// Defaults to 15 seconds.
+	LeaseDuration time.Duration
+	// RetryPeriod is how often candidates try to acquire the lock and the
+	// leader renews it. Defaults to a third of LeaseDuration.
+	RetryPeriod time.Duration
+}
+
+// Elector acquires and renews the leadership lock.
+type Elector struct {
+	restClient rest.Interface
+
+	namespace     string
+	name          string
+	identity      string
+	leaseDuration time.Duration
+	retryPeriod   time.Duration
+}
+
+// New creates an elector.
+func New(config Config) (*Elector, error) {
+	if config.RESTClient == nil {
+		return nil, fmt.Errorf("RESTClient must not be empty")
+	}
+	if config.Identity == "" {
+		return nil, fmt.Errorf("Identity must not be empty")
+	}
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.Name == "" {
+		config.Name = "operator-workshop-leader"
+	}
+	if config.LeaseDuration == 0 {
+		config.LeaseDuration = time.Second * 15
+	}
+	if config.RetryPeriod == 0 {
+		config.RetryPeriod = config.LeaseDuration / 3
+	}
+
+	elector := &Elector{
+		restClient: config.RESTClient,
+
+		namespace:     config.Namespace,
+		name:          config.Name,
+		identity:      config.Identity,
+		leaseDuration: config.LeaseDuration,
+		retryPeriod:   config.RetryPeriod,
+	}
+
+	return elector, nil
+}
+
+// record is the leader election record stored in the lock annotation. The
+// layout matches the record the Kubernetes control plane components use.
+type record struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime"`
+	RenewTime            string `json:"renewTime"`
+}
+
+// configMap is the subset of the core ConfigMap object used as lock. The
+// resourceVersion makes concurrent updates fail with a conflict, resolving
+// acquire races on the apiserver.
+type configMap struct {
+	APIVersion string          `json:"apiVersion,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Metadata   configMapObject `json:"metadata"`
+}
+
+type configMapObject struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// Run blocks until leadership is acquired or the context is cancelled. It
+// keeps renewing the leadership in the background and closes the returned
+// channel when the leadership is lost.
+func (e *Elector) Run(ctx context.Context) (<-chan struct{}, error) {
+	for {
+		acquired, err := e.tryAcquire(time.Now())
+		if err != nil {
+			logging.Warnf("electing leader: %s", err)
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(e.retryPeriod):
+		}
+	}
+
+	lostCh := make(chan struct{})
+	go e.renewLoop(ctx, lostCh)
+
+	return lostCh, nil
+}
+
+// renewLoop renews the leadership every retry period. The leadership is
+// lost when it couldn't be renewed for a full lease duration.
+func (e *Elector) renewLoop(ctx context.Context, lostCh chan struct{}) {
+	deadline := time.Now().Add(e.leaseDuration)
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(lostCh)
+			return
+		case <-time.After(e.retryPeriod):
+		}
+
+		renewed, err := e.tryAcquire(time.Now())
+		if err != nil {
+			logging.Warnf("renewing leadership: %s", err)
+		}
+		if renewed {
+			deadline = time.Now().Add(e.leaseDuration)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			close(lostCh)
+			return
+		}
+	}
+}
+
+// tryAcquire attempts to acquire or renew the lock. It returns false
+// without error when another candidate holds an unexpired lease or the
+// update lost a race.
+func (e *Elector) tryAcquire(now time.Time) (bool, error) {
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", e.namespace, e.name)
+
+	newRecord := record{
+		HolderIdentity:       e.identity,
+		LeaseDurationSeconds: int(e.leaseDuration / time.Second),
+		AcquireTime:          now.UTC().Format(time.RFC3339),
+		RenewTime:            now.UTC().Format(time.RFC3339),
+	}
+
+	body, err := e.restClient.Get().AbsPath(endpoint).DoRaw()
+	if apierrors.IsNotFound(err) {
+		return e.create(newRecord)
+	}
+	if err != nil {
+		return false, fmt.Errorf("requesting lock endpoint=%s: %s", endpoint, err)
+	}
+
+	var lock configMap
+	err = json.Unmarshal(body, &lock)
+	if err != nil {
+		return false, fmt.Errorf("decoding lock: %s", err)
+	}
+
+	var held record
+	if s, ok := lock.Metadata.Annotations[leaderAnnotation]; ok {
+		err := json.Unmarshal([]byte(s), &held)
+		if err != nil {
+			return false, fmt.Errorf("decoding leader record: %s", err)
+		}
+	}
+
+	if held.HolderIdentity != "" && held.HolderIdentity != e.identity {
+		renewTime, err := time.Parse(time.RFC3339, held.RenewTime)
+		expired := err != nil || now.After(renewTime.Add(time.Duration(held.LeaseDurationSeconds)*time.Second))
+		if !expired {
+			return false, nil
+		}
+	}
+
+	// Keep the original acquire time over renewals.
+	if held.HolderIdentity == e.identity && held.AcquireTime != "" {
+		newRecord.AcquireTime = held.AcquireTime
+	}
+
+	return e.update(lock, newRecord)
+}
+
+// create creates the lock ConfigMap holding the record. It returns false
+// without error when another candidate created the lock first.
+func (e *Elector) create(r record) (bool, error) {
+	recordJSON, err := json.Marshal(r)
+	if err != nil {
+		return false, fmt.Errorf("marshalling leader record: %s", err)
+	}
+
+	var lock configMap
+	lock.APIVersion = "v1"
+	lock.Kind = "ConfigMap"
+	lock.Metadata.Name = e.name
+	lock.Metadata.Namespace = e.namespace
+	lock.Metadata.Annotations = map[string]string{
+		leaderAnnotation: string(recordJSON),
+	}
+
+	body, err := json.Marshal(lock)
+	if err != nil {
+		return false, fmt.Errorf("marshalling lock: %s", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/configmaps", e.namespace)
+	_, err = e.restClient.Post().AbsPath(endpoint).Body(body).DoRaw()
+	if apierrors.IsAlreadyExists(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("creating lock endpoint=%s: %s", endpoint, err)
+	}
+
+	return true, nil
+}
+
+// update writes the record to the existing lock. It returns false without
+// error when the update lost a resourceVersion conflict.
+func (e *Elector) update(lock configMap, r record) (bool, error) {
+	recordJSON, err := json.Marshal(r)
+	if err != nil {
+		return false, fmt.Errorf("marshalling leader record: %s", err)
+	}
+
+	if lock.Metadata.Annotations == nil {
+		lock.Metadata.Annotations = map[string]string{}
+	}
+	lock.Metadata.Annotations[leaderAnnotation] = string(recordJSON)
+
+	body, err := json.Marshal(lock)
+	if err != nil {
+		return false, fmt.Errorf("marshalling lock: %s", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", e.namespace, e.name)
+	_, err = e.restClient.Put().AbsPath(endpoint).Body(body).DoRaw()
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("updating lock endpoint=%s: %s", endpoint, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,186 @@
+// Package logging is the shared logging backend of the solutions. Records
+// carry a level and optional key/value context fields and are written as
+// text lines, mimicking the original "I " prefixed output, or as JSON,
+// selectable at startup. Call sites use the package level printf functions,
+// per-object context is attached with With.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a record. Records below the configured minimum
+// level are dropped.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames maps levels to the names used in flags and JSON output. The
+// text format uses the first letter, matching the old "I "/"E " prefixes.
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// ParseLevel parses a level name as used in the --log-level flag.
+func ParseLevel(s string) (Level, error) {
+	for level, name := range levelNames {
+		if name == s {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %#q", s)
+}
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config is the logger configuration.
+type Config struct {
+	// Format is "text" or "json". Defaults to text.
+	Format string
+	// Level is the minimum level written, one of "debug", "info", "warn"
+	// and "error". Defaults to info.
+	Level string
+	// Writer defaults to standard error, like the standard log package.
+	Writer io.Writer
+}
+
+// Logger writes leveled records.
+type Logger struct {
+	format string
+	level  Level
+	writer io.Writer
+	mutex  *sync.Mutex
+	fields []interface{}
+}
+
+// New creates a logger.
+func New(config Config) (*Logger, error) {
+	if config.Format == "" {
+		config.Format = FormatText
+	}
+	if config.Format != FormatText && config.Format != FormatJSON {
+		return nil, fmt.Errorf("unknown log format %#q", config.Format)
+	}
+	if config.Level == "" {
+		config.Level = "info"
+	}
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+	if config.Writer == nil {
+		config.Writer = os.Stderr
+	}
+
+	return &Logger{
+		format: config.Format,
+		level:  level,
+		writer: config.Writer,
+		mutex:  &sync.Mutex{},
+	}, nil
+}
+
+// With returns a logger attaching the key/value pairs to every record, e.g.
+// the namespace and name of the reconciled object.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+
+	return &Logger{
+		format: l.format,
+		level:  l.level,
+		writer: l.writer,
+		mutex:  l.mutex,
+		fields: fields,
+	}
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) { l.logf(LevelDebug, format, v...) }
+func (l *Logger) Infof(format string, v ...interface{})  { l.logf(LevelInfo, format, v...) }
+func (l *Logger) Warnf(format string, v ...interface{})  { l.logf(LevelWarn, format, v...) }
+func (l *Logger) Errorf(format string, v ...interface{}) { l.logf(LevelError, format, v...) }
+
+func (l *Logger) logf(level Level, format string, v ...interface{}) {
+	if level < l.level {
+		return
+	}
+
+	message := fmt.Sprintf(format, v...)
+	now := time.Now().UTC()
+
+	var line string
+	if l.format == FormatJSON {
+		record := map[string]interface{}{
+			"time":    now.Format(time.RFC3339Nano),
+			"level":   levelNames[level],
+			"message": message,
+		}
+		for i := 0; i+1 < len(l.fields); i += 2 {
+			record[fmt.Sprintf("%v", l.fields[i])] = fmt.Sprintf("%v", l.fields[i+1])
+		}
+		b, err := json.Marshal(record)
+		if err != nil {
+			// Fall back to a plain line, a record must never be lost
+			// to a marshalling error.
+			b = []byte(fmt.Sprintf(`{"level":%q,"message":%q}`, levelNames[level], message))
+		}
+		line = string(b)
+	} else {
+		line = fmt.Sprintf("%c %s %s", levelNames[level][0]-32, now.Format("2006/01/02 15:04:05"), message)
+		for i := 0; i+1 < len(l.fields); i += 2 {
+			line += fmt.Sprintf(" %v=%v", l.fields[i], l.fields[i+1])
+		}
+	}
+
+	l.mutex.Lock()
+	fmt.Fprintln(l.writer, line)
+	l.mutex.Unlock()
+}
+
+// std is the default logger used by the package level functions. It writes
+// text at info level until Configure replaces it.
+var std = func() *Logger {
+	l, err := New(Config{})
+	if err != nil {
+		panic(err)
+	}
+	return l
+}()
+
+// Configure replaces the default logger, typically right after flag parsing.
+func Configure(config Config) error {
+	l, err := New(config)
+	if err != nil {
+		return err
+	}
+	std = l
+	return nil
+}
+
+func Debugf(format string, v ...interface{}) { std.logf(LevelDebug, format, v...) }
+func Infof(format string, v ...interface{})  { std.logf(LevelInfo, format, v...) }
+func Warnf(format string, v ...interface{})  { std.logf(LevelWarn, format, v...) }
+func Errorf(format string, v ...interface{}) { std.logf(LevelError, format, v...) }
+
+// With returns a logger derived from the default logger attaching the
+// key/value pairs to every record.
+func With(keyvals ...interface{}) *Logger {
+	return std.With(keyvals...)
+}
@@ -0,0 +1,75 @@
+package mysqlops
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/operator-workshop/opstest"
+)
+
+func TestFakeConformance(t *testing.T) {
+	fake := NewFake(FakeConfig{})
+
+	backend := opstest.Backend{
+		CreateDatabase:      fake.CreateDatabase,
+		ChangeDatabaseOwner: fake.ChangeDatabaseOwner,
+		DeleteDatabase:      fake.DeleteDatabase,
+		ListDatabases: func() (map[string]string, error) {
+			dbs := map[string]string{}
+			for name, db := range fake.Snapshot() {
+				dbs[name] = db.Owner
+			}
+			return dbs, nil
+		},
+	}
+
+	opstest.Run(t, backend)
+}
+
+// TestMySQLConformance runs the conformance suite against a real MySQL
+// server, e.g. one started with docker. It is skipped unless
+// MYSQLOPS_TEST_HOST is set.
+func TestMySQLConformance(t *testing.T) {
+	host := os.Getenv("MYSQLOPS_TEST_HOST")
+	if host == "" {
+		t.Skip("set MYSQLOPS_TEST_HOST to run against a real MySQL server")
+	}
+
+	port, err := strconv.Atoi(os.Getenv("MYSQLOPS_TEST_PORT"))
+	if err != nil {
+		port = 3306
+	}
+
+	config := Config{
+		Host:     host,
+		Port:     port,
+		User:     os.Getenv("MYSQLOPS_TEST_USER"),
+		Password: os.Getenv("MYSQLOPS_TEST_PASSWORD"),
+	}
+
+	ops, err := New(config)
+	if err != nil {
+		t.Fatalf("creating MySQLOps: %s", err)
+	}
+	defer ops.Close()
+
+	backend := opstest.Backend{
+		CreateDatabase:      ops.CreateDatabase,
+		ChangeDatabaseOwner: ops.ChangeDatabaseOwner,
+		DeleteDatabase:      ops.DeleteDatabase,
+		ListDatabases: func() (map[string]string, error) {
+			dbs, err := ops.ListDatabases()
+			if err != nil {
+				return nil, err
+			}
+			m := map[string]string{}
+			for _, db := range dbs {
+				m[db.Name] = db.Owner
+			}
+			return m, nil
+		},
+	}
+
+	opstest.Run(t, backend)
+}
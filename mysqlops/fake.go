@@ -0,0 +1,218 @@
+package mysqlops
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FakeConfig configures the failure modes of the in-memory fake.
+type FakeConfig struct {
+	// ErrorEveryN makes every Nth call return an injected error. Zero
+	// disables error injection.
+	ErrorEveryN int
+	// MaxLatency delays every call by a random duration up to the
+	// configured maximum. Zero disables latency simulation.
+	MaxLatency time.Duration
+}
+
+// Fake is an in-memory MySQLOps replacement. It lets workshop attendees
+// test operator retry and backoff behavior, including injected errors,
+// random latency and temporary unavailability, without breaking a real
+// server.
+type Fake struct {
+	config FakeConfig
+
+	mutex       sync.Mutex
+	calls       int
+	unavailable bool
+	databases   map[string]Database
+	users       map[string]bool
+}
+
+// NewFake creates an in-memory fake with the given failure modes.
+func NewFake(config FakeConfig) *Fake {
+	return &Fake{
+		config: config,
+
+		databases: map[string]Database{},
+		users:     map[string]bool{},
+	}
+}
+
+// SetUnavailable toggles simulated server unavailability. While unavailable
+// every call fails.
+func (f *Fake) SetUnavailable(unavailable bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.unavailable = unavailable
+}
+
+// Close releases all Fake resources.
+func (f *Fake) Close() error {
+	return nil
+}
+
+// CreateDatabase creates a database and owner if they don't exist.
+func (f *Fake) CreateDatabase(name, owner string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return err
+	}
+
+	f.users[owner] = true
+	if _, ok := f.databases[name]; !ok {
+		f.databases[name] = Database{Name: name, Owner: owner}
+	}
+
+	return nil
+}
+
+// ChangeDatabaseOwner changes the database owner and creates the user if it
+// doesn't exist.
+func (f *Fake) ChangeDatabaseOwner(name, owner string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return err
+	}
+
+	db, ok := f.databases[name]
+	if !ok {
+		return fmt.Errorf("changing owner: database=%#q does not exist", name)
+	}
+
+	f.users[owner] = true
+	db.Owner = owner
+	f.databases[name] = db
+
+	return nil
+}
+
+// SetDatabaseCharset changes the default character set and collation of the
+// database.
+func (f *Fake) SetDatabaseCharset(name, characterSet, collation string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return err
+	}
+
+	db, ok := f.databases[name]
+	if !ok {
+		return fmt.Errorf("setting database charset: database=%#q does not exist", name)
+	}
+
+	if characterSet != "" {
+		db.CharacterSet = characterSet
+	}
+	if collation != "" {
+		db.Collation = collation
+	}
+	f.databases[name] = db
+
+	return nil
+}
+
+// DeleteDatabase deletes a database if it exists.
+func (f *Fake) DeleteDatabase(name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return err
+	}
+
+	delete(f.databases, name)
+
+	return nil
+}
+
+// ListDatabases lists the databases.
+func (f *Fake) ListDatabases() ([]Database, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return []Database{}, err
+	}
+
+	dbs := []Database{}
+	for _, db := range f.databases {
+		dbs = append(dbs, db)
+	}
+
+	return dbs, nil
+}
+
+// ListUsers lists the database users.
+func (f *Fake) ListUsers() ([]string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return []string{}, err
+	}
+
+	users := []string{}
+	for user := range f.users {
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes a user if it exists.
+func (f *Fake) DeleteUser(name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.begin(); err != nil {
+		return err
+	}
+
+	delete(f.users, name)
+
+	return nil
+}
+
+// Snapshot returns a copy of the fake's databases keyed by name for test
+// assertions. All state is held per instance, so fakes can run in parallel
+// tests without sharing state. Snapshot is not subject to the configured
+// failure modes.
+func (f *Fake) Snapshot() map[string]Database {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	snapshot := map[string]Database{}
+	for name, db := range f.databases {
+		snapshot[name] = db
+	}
+
+	return snapshot
+}
+
+// begin applies the configured failure modes to one call. It must be called
+// with the mutex held.
+func (f *Fake) begin() error {
+	if f.config.MaxLatency > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(f.config.MaxLatency))))
+	}
+
+	if f.unavailable {
+		return fmt.Errorf("fake mysql: server is unavailable")
+	}
+
+	f.calls++
+	if f.config.ErrorEveryN > 0 && f.calls%f.config.ErrorEveryN == 0 {
+		return fmt.Errorf("fake mysql: injected error on call %d", f.calls)
+	}
+
+	return nil
+}
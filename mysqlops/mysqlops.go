@@ -0,0 +1,375 @@
+package mysqlops
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/giantswarm/operator-workshop/logging"
+
+	// The MySQL driver is imported by name for RegisterTLSConfig only.
+	// All data access is via database/sql.
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name the custom TLS configuration is registered under
+// with the driver and referenced by in the DSN.
+const tlsConfigName = "operator-workshop"
+
+// Database is a database managed by the operator.
+type Database struct {
+	Name  string
+	Owner string
+	// CharacterSet is the default character set of the database.
+	CharacterSet string
+	// Collation is the default collation of the database.
+	Collation string
+}
+
+// Config is the database connection configuration.
+type Config struct {
+	Host string
+	Port int
+
+	User     string
+	Password string
+
+	// TLSCAFile enables TLS when set and is the path of the CA
+	// certificate file.
+	TLSCAFile string
+	// TLSCertFile is the path of the client certificate file.
+	TLSCertFile string
+	// TLSKeyFile is the path of the client key file.
+	TLSKeyFile string
+
+	// AuthPlugin selects the authentication plugin, either
+	// "caching_sha2_password" (the MySQL 8 default, negotiated by the
+	// driver) or "mysql_native_password". Empty lets the driver decide.
+	AuthPlugin string
+
+	// DryRun makes every mutating method log the SQL it would execute
+	// and skip execution.
+	DryRun bool
+}
+
+// MySQLOps has the database handle for connecting to the database server. It
+// mirrors the postgresqlops surface so solutions can manage both backends
+// the same way.
+type MySQLOps struct {
+	config Config
+
+	db *sql.DB
+}
+
+// New creates the connection to the database server.
+func New(config Config) (*MySQLOps, error) {
+	if config.TLSCAFile != "" {
+		err := registerTLSConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("registering TLS config: %s", err)
+		}
+	}
+
+	db, err := sql.Open("mysql", connectionString(config))
+	if err != nil {
+		return nil, fmt.Errorf("creating mysql client: %s", err)
+	}
+
+	mySQLOps := &MySQLOps{
+		config: config,
+
+		db: db,
+	}
+
+	return mySQLOps, nil
+}
+
+// connectionString builds the go-sql-driver DSN from the configuration.
+func connectionString(config Config) string {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/", config.User, config.Password, config.Host, config.Port)
+
+	params := []string{}
+	if config.TLSCAFile != "" {
+		params = append(params, "tls="+tlsConfigName)
+	}
+	if config.AuthPlugin == "mysql_native_password" {
+		params = append(params, "allowNativePasswords=true")
+	}
+
+	if len(params) > 0 {
+		dsn += "?" + strings.Join(params, "&")
+	}
+
+	return dsn
+}
+
+// registerTLSConfig registers the TLS configuration with the driver so the
+// DSN can reference it, enabling MySQL 8 style TLS connections.
+func registerTLSConfig(config Config) error {
+	certPool := x509.NewCertPool()
+	caCert, err := ioutil.ReadFile(config.TLSCAFile)
+	if err != nil {
+		return err
+	}
+	certPool.AppendCertsFromPEM(caCert)
+
+	tlsConfig := &tls.Config{
+		RootCAs: certPool,
+	}
+
+	if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+		crt, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{crt}
+	}
+
+	return mysql.RegisterTLSConfig(tlsConfigName, tlsConfig)
+}
+
+// Close releases all MySQLOps resources.
+func (m *MySQLOps) Close() error {
+	return m.db.Close()
+}
+
+// exec executes a mutating statement. In dry-run mode the statement is only
+// logged so the reconciliation decisions can be observed safely.
+func (m *MySQLOps) exec(query string) error {
+	if m.config.DryRun {
+		logging.Infof("dry-run: would execute %#q", query)
+		return nil
+	}
+
+	_, err := m.db.Exec(query)
+	return err
+}
+
+// CreateDatabase creates a database and owner if they don't exist. MySQL has
+// no database ownership, so the owner is the user granted all privileges on
+// the database.
+func (m *MySQLOps) CreateDatabase(name, owner string) error {
+	ownerExists, err := m.hasUser(owner)
+	if err != nil {
+		return fmt.Errorf("checking owner exists: %s", err)
+	}
+	if !ownerExists {
+		err := m.createUser(owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	createDb := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", name)
+	err = m.exec(createDb)
+	if err != nil {
+		return fmt.Errorf("creating database: %s", err)
+	}
+
+	err = m.grantAll(name, owner)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChangeDatabaseOwner moves the all-privileges grant on the database to the
+// new owner, creating the user if it doesn't exist.
+func (m *MySQLOps) ChangeDatabaseOwner(name, owner string) error {
+	ownerExists, err := m.hasUser(owner)
+	if err != nil {
+		return fmt.Errorf("checking owner exists: %s", err)
+	}
+	if !ownerExists {
+		err := m.createUser(owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	oldOwner, err := m.databaseOwner(name)
+	if err != nil {
+		return err
+	}
+	if oldOwner != "" && oldOwner != owner {
+		revoke := fmt.Sprintf("REVOKE ALL PRIVILEGES ON `%s`.* FROM '%s'@'%%'", name, oldOwner)
+		err := m.exec(revoke)
+		if err != nil {
+			return fmt.Errorf("revoking privileges from owner=%#q: %s", oldOwner, err)
+		}
+	}
+
+	err = m.grantAll(name, owner)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetDatabaseCharset changes the default character set and collation of the
+// database. Empty values are left to the server defaults.
+func (m *MySQLOps) SetDatabaseCharset(name, characterSet, collation string) error {
+	if characterSet == "" && collation == "" {
+		return nil
+	}
+
+	alterDb := fmt.Sprintf("ALTER DATABASE `%s`", name)
+	if characterSet != "" {
+		alterDb += fmt.Sprintf(" CHARACTER SET %s", characterSet)
+	}
+	if collation != "" {
+		alterDb += fmt.Sprintf(" COLLATE %s", collation)
+	}
+
+	err := m.exec(alterDb)
+	if err != nil {
+		return fmt.Errorf("setting database charset: %s", err)
+	}
+
+	return nil
+}
+
+// DeleteDatabase deletes a database if it exists.
+func (m *MySQLOps) DeleteDatabase(name string) error {
+	deleteDb := fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name)
+	err := m.exec(deleteDb)
+	if err != nil {
+		return fmt.Errorf("deleting database: %s", err)
+	}
+
+	return nil
+}
+
+// ListDatabases lists the databases with their owners, i.e. the user holding
+// the all-privileges grant. System schemas are excluded so orphan-deletion
+// logic can never try to drop them.
+func (m *MySQLOps) ListDatabases() ([]Database, error) {
+	dbs := []Database{}
+
+	rows, err := m.db.Query("SELECT SCHEMA_NAME, DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')")
+	if err != nil {
+		return []Database{}, fmt.Errorf("listing databases: %s", err)
+	}
+
+	defer rows.Close()
+
+	var dbName, characterSet, collation string
+
+	for rows.Next() {
+		err := rows.Scan(&dbName, &characterSet, &collation)
+		if err != nil {
+			return []Database{}, fmt.Errorf("getting database values: %s", err)
+		}
+
+		owner, err := m.databaseOwner(dbName)
+		if err != nil {
+			return []Database{}, err
+		}
+
+		dbs = append(dbs, Database{Name: dbName, Owner: owner, CharacterSet: characterSet, Collation: collation})
+	}
+
+	return dbs, nil
+}
+
+// ListUsers lists the database users excluding the configured admin user.
+func (m *MySQLOps) ListUsers() ([]string, error) {
+	users := []string{}
+
+	rows, err := m.db.Query("SELECT User FROM mysql.user WHERE Host = '%'")
+	if err != nil {
+		return []string{}, fmt.Errorf("listing users: %s", err)
+	}
+
+	defer rows.Close()
+
+	var user string
+
+	for rows.Next() {
+		err := rows.Scan(&user)
+		if err != nil {
+			return []string{}, fmt.Errorf("getting user values: %s", err)
+		}
+
+		if user == m.config.User {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes a user if it exists.
+func (m *MySQLOps) DeleteUser(name string) error {
+	deleteUser := fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%'", name)
+	err := m.exec(deleteUser)
+	if err != nil {
+		return fmt.Errorf("deleting user: %s", err)
+	}
+
+	return nil
+}
+
+// databaseOwner returns the user holding the all-privileges grant on the
+// database, or empty when there is none.
+func (m *MySQLOps) databaseOwner(name string) (string, error) {
+	rows, err := m.db.Query("SELECT User FROM mysql.db WHERE Db = ? AND Select_priv = 'Y' AND Insert_priv = 'Y' AND Drop_priv = 'Y' AND Grant_priv = 'N' ORDER BY User LIMIT 1", name)
+	if err != nil {
+		return "", fmt.Errorf("getting database owner: %s", err)
+	}
+
+	defer rows.Close()
+
+	var owner string
+
+	if rows.Next() {
+		err := rows.Scan(&owner)
+		if err != nil {
+			return "", fmt.Errorf("getting database owner values: %s", err)
+		}
+	}
+
+	return owner, nil
+}
+
+func (m *MySQLOps) grantAll(name, owner string) error {
+	grant := fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO '%s'@'%%'", name, owner)
+	err := m.exec(grant)
+	if err != nil {
+		return fmt.Errorf("granting privileges to owner=%#q: %s", owner, err)
+	}
+
+	return nil
+}
+
+func (m *MySQLOps) createUser(user string) error {
+	createUser := fmt.Sprintf("CREATE USER IF NOT EXISTS '%s'@'%%'", user)
+	err := m.exec(createUser)
+	if err != nil {
+		return fmt.Errorf("creating user: %s", err)
+	}
+
+	return nil
+}
+
+func (m *MySQLOps) hasUser(name string) (bool, error) {
+	users, err := m.ListUsers()
+	if err != nil {
+		return false, fmt.Errorf("checking user exists: %s", err)
+	}
+
+	for _, user := range users {
+		if user == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
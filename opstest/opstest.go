@@ -0,0 +1,95 @@
+// Package opstest provides a conformance suite asserting behavioral parity
+// between the database ops backends, i.e. postgresqlops, mysqlops and the
+// in-memory fake.
+package opstest
+
+import (
+	"testing"
+)
+
+// Backend adapts one ops implementation to the conformance suite. The
+// backends return different Database types, so listing is adapted to a
+// name to owner map.
+type Backend struct {
+	CreateDatabase      func(name, owner string) error
+	ChangeDatabaseOwner func(name, owner string) error
+	DeleteDatabase      func(name string) error
+	// ListDatabases returns the managed databases as a name to owner map.
+	ListDatabases func() (map[string]string, error)
+}
+
+// Run runs the same scenarios every ops backend has to pass: create, change
+// owner, idempotent re-create and delete.
+func Run(t *testing.T, backend Backend) {
+	name := "opstest_db"
+	owner := "opstest_owner"
+	newOwner := "opstest_owner_changed"
+
+	// Make sure leftovers from aborted runs don't fail the suite.
+	err := backend.DeleteDatabase(name)
+	if err != nil {
+		t.Fatalf("deleting leftover database: %s", err)
+	}
+
+	// Create.
+	err = backend.CreateDatabase(name, owner)
+	if err != nil {
+		t.Fatalf("creating database: %s", err)
+	}
+	assertOwner(t, backend, name, owner)
+
+	// Idempotent re-create.
+	err = backend.CreateDatabase(name, owner)
+	if err != nil {
+		t.Fatalf("re-creating database: %s", err)
+	}
+	assertOwner(t, backend, name, owner)
+
+	// Change owner.
+	err = backend.ChangeDatabaseOwner(name, newOwner)
+	if err != nil {
+		t.Fatalf("changing owner: %s", err)
+	}
+	assertOwner(t, backend, name, newOwner)
+
+	// Delete.
+	err = backend.DeleteDatabase(name)
+	if err != nil {
+		t.Fatalf("deleting database: %s", err)
+	}
+	assertDeleted(t, backend, name)
+
+	// Idempotent delete.
+	err = backend.DeleteDatabase(name)
+	if err != nil {
+		t.Fatalf("re-deleting database: %s", err)
+	}
+	assertDeleted(t, backend, name)
+}
+
+func assertOwner(t *testing.T, backend Backend, name, owner string) {
+	dbs, err := backend.ListDatabases()
+	if err != nil {
+		t.Fatalf("listing databases: %s", err)
+	}
+
+	got, ok := dbs[name]
+	if !ok {
+		t.Fatalf("database=%#q not found", name)
+	}
+	if got != owner {
+		t.Fatalf("database=%#q owner=%#q, want %#q", name, got, owner)
+	}
+}
+
+func assertDeleted(t *testing.T, backend Backend, name string) {
+	dbs, err := backend.ListDatabases()
+	if err != nil {
+		t.Fatalf("listing databases: %s", err)
+	}
+
+	_, ok := dbs[name]
+	if ok {
+		t.Fatalf("database=%#q still exists", name)
+	}
+}
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"github.com/giantswarm/operator-workshop/customobject"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgreSQLConfig is the canonical API representation of
+// postgresqlconfigs.containerconf.de objects. The reconciliation payload is
+// shared with the raw HTTP solutions via the inlined customobject type.
+type PostgreSQLConfig struct {
+	apismetav1.TypeMeta   `json:",inline"`
+	apismetav1.ObjectMeta `json:"metadata,omitempty"`
+
+	customobject.PostgreSQLConfig `json:",inline"`
+}
+
+// PostgreSQLConfigList is a list of PostgreSQLConfig objects.
+type PostgreSQLConfigList struct {
+	apismetav1.TypeMeta `json:",inline"`
+	apismetav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PostgreSQLConfig `json:"items"`
+}
+
+// PostgreSQLServer is the canonical API representation of
+// postgresqlservers.containerconf.de objects.
+type PostgreSQLServer struct {
+	apismetav1.TypeMeta   `json:",inline"`
+	apismetav1.ObjectMeta `json:"metadata,omitempty"`
+
+	customobject.PostgreSQLServer `json:",inline"`
+}
+
+// PostgreSQLServerList is a list of PostgreSQLServer objects.
+type PostgreSQLServerList struct {
+	apismetav1.TypeMeta `json:",inline"`
+	apismetav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PostgreSQLServer `json:"items"`
+}
+
+// MySQLConfig is the canonical API representation of
+// mysqlconfigs.containerconf.de objects.
+type MySQLConfig struct {
+	apismetav1.TypeMeta   `json:",inline"`
+	apismetav1.ObjectMeta `json:"metadata,omitempty"`
+
+	customobject.MySQLConfig `json:",inline"`
+}
+
+// MySQLConfigList is a list of MySQLConfig objects.
+type MySQLConfigList struct {
+	apismetav1.TypeMeta `json:",inline"`
+	apismetav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []MySQLConfig `json:"items"`
+}
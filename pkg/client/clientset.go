@@ -0,0 +1,40 @@
+// Package client provides a typed clientset for the containerconf.de API
+// group, hand-written following the client-gen conventions.
+package client
+
+import (
+	rest "k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/client/containerconf/v1"
+)
+
+type Interface interface {
+	ContainerconfV1() containerconfv1.ContainerconfV1Interface
+}
+
+// Clientset contains the clients for the containerconf.de API group versions.
+type Clientset struct {
+	*containerconfv1.ContainerconfV1Client
+}
+
+// ContainerconfV1 retrieves the ContainerconfV1Client.
+func (c *Clientset) ContainerconfV1() containerconfv1.ContainerconfV1Interface {
+	if c == nil {
+		return nil
+	}
+	return c.ContainerconfV1Client
+}
+
+// NewForConfig creates a new Clientset for the given config.
+func NewForConfig(c *rest.Config) (*Clientset, error) {
+	configShallowCopy := *c
+
+	var cs Clientset
+	var err error
+	cs.ContainerconfV1Client, err = containerconfv1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cs, nil
+}
@@ -0,0 +1,77 @@
+// Package v1 is a typed client for the containerconf.de/v1 API group. It is
+// hand-written following the client-gen conventions so the solutions can
+// drop the raw REST paths and hand-rolled decoders.
+package v1
+
+import (
+	serializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	rest "k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	"github.com/giantswarm/operator-workshop/pkg/client/scheme"
+)
+
+type ContainerconfV1Interface interface {
+	RESTClient() rest.Interface
+	PostgreSQLConfigsGetter
+	PostgreSQLServersGetter
+	MySQLConfigsGetter
+}
+
+// ContainerconfV1Client is used to interact with features provided by the
+// containerconf.de group.
+type ContainerconfV1Client struct {
+	restClient rest.Interface
+}
+
+func (c *ContainerconfV1Client) PostgreSQLConfigs(namespace string) PostgreSQLConfigInterface {
+	return newPostgreSQLConfigs(c, namespace)
+}
+
+func (c *ContainerconfV1Client) PostgreSQLServers(namespace string) PostgreSQLServerInterface {
+	return newPostgreSQLServers(c, namespace)
+}
+
+func (c *ContainerconfV1Client) MySQLConfigs(namespace string) MySQLConfigInterface {
+	return newMySQLConfigs(c, namespace)
+}
+
+// NewForConfig creates a new ContainerconfV1Client for the given config.
+func NewForConfig(c *rest.Config) (*ContainerconfV1Client, error) {
+	config := *c
+	if err := setConfigDefaults(&config); err != nil {
+		return nil, err
+	}
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerconfV1Client{client}, nil
+}
+
+// New creates a new ContainerconfV1Client for the given RESTClient.
+func New(c rest.Interface) *ContainerconfV1Client {
+	return &ContainerconfV1Client{c}
+}
+
+func setConfigDefaults(config *rest.Config) error {
+	gv := containerconfv1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	return nil
+}
+
+// RESTClient returns a RESTClient that is used to communicate with the API
+// server by this client implementation.
+func (c *ContainerconfV1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
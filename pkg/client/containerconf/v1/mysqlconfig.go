@@ -0,0 +1,118 @@
+package v1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	scheme "github.com/giantswarm/operator-workshop/pkg/client/scheme"
+)
+
+// MySQLConfigsGetter has a method to return a MySQLConfigInterface. A
+// group's client should implement this interface.
+type MySQLConfigsGetter interface {
+	MySQLConfigs(namespace string) MySQLConfigInterface
+}
+
+// MySQLConfigInterface has methods to work with MySQLConfig resources.
+type MySQLConfigInterface interface {
+	Create(*containerconfv1.MySQLConfig) (*containerconfv1.MySQLConfig, error)
+	Update(*containerconfv1.MySQLConfig) (*containerconfv1.MySQLConfig, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	Get(name string, options v1.GetOptions) (*containerconfv1.MySQLConfig, error)
+	List(opts v1.ListOptions) (*containerconfv1.MySQLConfigList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+}
+
+// mySQLConfigs implements MySQLConfigInterface.
+type mySQLConfigs struct {
+	client rest.Interface
+	ns     string
+}
+
+// newMySQLConfigs returns a mySQLConfigs.
+func newMySQLConfigs(c *ContainerconfV1Client, namespace string) *mySQLConfigs {
+	return &mySQLConfigs{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Create takes the representation of a mySQLConfig and creates it. Returns
+// the server's representation of the mySQLConfig, and an error, if there is
+// any.
+func (c *mySQLConfigs) Create(mySQLConfig *containerconfv1.MySQLConfig) (result *containerconfv1.MySQLConfig, err error) {
+	result = &containerconfv1.MySQLConfig{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		Body(mySQLConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a mySQLConfig and updates it. Returns
+// the server's representation of the mySQLConfig, and an error, if there is
+// any.
+func (c *mySQLConfigs) Update(mySQLConfig *containerconfv1.MySQLConfig) (result *containerconfv1.MySQLConfig, err error) {
+	result = &containerconfv1.MySQLConfig{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		Name(mySQLConfig.Name).
+		Body(mySQLConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the mySQLConfig and deletes it. Returns an error if
+// one occurs.
+func (c *mySQLConfigs) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the mySQLConfig, and returns the corresponding
+// mySQLConfig object, and an error if there is any.
+func (c *mySQLConfigs) Get(name string, options v1.GetOptions) (result *containerconfv1.MySQLConfig, err error) {
+	result = &containerconfv1.MySQLConfig{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of
+// MySQLConfigs that match those selectors.
+func (c *mySQLConfigs) List(opts v1.ListOptions) (result *containerconfv1.MySQLConfigList, err error) {
+	result = &containerconfv1.MySQLConfigList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested mySQLConfigs.
+func (c *mySQLConfigs) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("mysqlconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
@@ -0,0 +1,120 @@
+package v1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	scheme "github.com/giantswarm/operator-workshop/pkg/client/scheme"
+)
+
+// PostgreSQLConfigsGetter has a method to return a PostgreSQLConfigInterface.
+// A group's client should implement this interface.
+type PostgreSQLConfigsGetter interface {
+	PostgreSQLConfigs(namespace string) PostgreSQLConfigInterface
+}
+
+// PostgreSQLConfigInterface has methods to work with PostgreSQLConfig
+// resources.
+type PostgreSQLConfigInterface interface {
+	Create(*containerconfv1.PostgreSQLConfig) (*containerconfv1.PostgreSQLConfig, error)
+	Update(*containerconfv1.PostgreSQLConfig) (*containerconfv1.PostgreSQLConfig, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	Get(name string, options v1.GetOptions) (*containerconfv1.PostgreSQLConfig, error)
+	List(opts v1.ListOptions) (*containerconfv1.PostgreSQLConfigList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+}
+
+// postgreSQLConfigs implements PostgreSQLConfigInterface.
+type postgreSQLConfigs struct {
+	client rest.Interface
+	ns     string
+}
+
+// newPostgreSQLConfigs returns a postgreSQLConfigs.
+func newPostgreSQLConfigs(c *ContainerconfV1Client, namespace string) *postgreSQLConfigs {
+	return &postgreSQLConfigs{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Create takes the representation of a postgreSQLConfig and creates it.
+// Returns the server's representation of the postgreSQLConfig, and an error,
+// if there is any.
+func (c *postgreSQLConfigs) Create(postgreSQLConfig *containerconfv1.PostgreSQLConfig) (result *containerconfv1.PostgreSQLConfig, err error) {
+	result = &containerconfv1.PostgreSQLConfig{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		Body(postgreSQLConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a postgreSQLConfig and updates it.
+// Returns the server's representation of the postgreSQLConfig, and an error,
+// if there is any.
+func (c *postgreSQLConfigs) Update(postgreSQLConfig *containerconfv1.PostgreSQLConfig) (result *containerconfv1.PostgreSQLConfig, err error) {
+	result = &containerconfv1.PostgreSQLConfig{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		Name(postgreSQLConfig.Name).
+		Body(postgreSQLConfig).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the postgreSQLConfig and deletes it. Returns an error
+// if one occurs.
+func (c *postgreSQLConfigs) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the postgreSQLConfig, and returns the corresponding
+// postgreSQLConfig object, and an error if there is any.
+func (c *postgreSQLConfigs) Get(name string, options v1.GetOptions) (result *containerconfv1.PostgreSQLConfig, err error) {
+	result = &containerconfv1.PostgreSQLConfig{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of
+// PostgreSQLConfigs that match those selectors.
+func (c *postgreSQLConfigs) List(opts v1.ListOptions) (result *containerconfv1.PostgreSQLConfigList, err error) {
+	result = &containerconfv1.PostgreSQLConfigList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested
+// postgreSQLConfigs.
+func (c *postgreSQLConfigs) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlconfigs").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
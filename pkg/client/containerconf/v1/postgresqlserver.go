@@ -0,0 +1,120 @@
+package v1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	scheme "github.com/giantswarm/operator-workshop/pkg/client/scheme"
+)
+
+// PostgreSQLServersGetter has a method to return a PostgreSQLServerInterface.
+// A group's client should implement this interface.
+type PostgreSQLServersGetter interface {
+	PostgreSQLServers(namespace string) PostgreSQLServerInterface
+}
+
+// PostgreSQLServerInterface has methods to work with PostgreSQLServer
+// resources.
+type PostgreSQLServerInterface interface {
+	Create(*containerconfv1.PostgreSQLServer) (*containerconfv1.PostgreSQLServer, error)
+	Update(*containerconfv1.PostgreSQLServer) (*containerconfv1.PostgreSQLServer, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	Get(name string, options v1.GetOptions) (*containerconfv1.PostgreSQLServer, error)
+	List(opts v1.ListOptions) (*containerconfv1.PostgreSQLServerList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+}
+
+// postgreSQLServers implements PostgreSQLServerInterface.
+type postgreSQLServers struct {
+	client rest.Interface
+	ns     string
+}
+
+// newPostgreSQLServers returns a postgreSQLServers.
+func newPostgreSQLServers(c *ContainerconfV1Client, namespace string) *postgreSQLServers {
+	return &postgreSQLServers{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Create takes the representation of a postgreSQLServer and creates it.
+// Returns the server's representation of the postgreSQLServer, and an error,
+// if there is any.
+func (c *postgreSQLServers) Create(postgreSQLServer *containerconfv1.PostgreSQLServer) (result *containerconfv1.PostgreSQLServer, err error) {
+	result = &containerconfv1.PostgreSQLServer{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		Body(postgreSQLServer).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a postgreSQLServer and updates it.
+// Returns the server's representation of the postgreSQLServer, and an error,
+// if there is any.
+func (c *postgreSQLServers) Update(postgreSQLServer *containerconfv1.PostgreSQLServer) (result *containerconfv1.PostgreSQLServer, err error) {
+	result = &containerconfv1.PostgreSQLServer{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		Name(postgreSQLServer.Name).
+		Body(postgreSQLServer).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the postgreSQLServer and deletes it. Returns an error
+// if one occurs.
+func (c *postgreSQLServers) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the postgreSQLServer, and returns the corresponding
+// postgreSQLServer object, and an error if there is any.
+func (c *postgreSQLServers) Get(name string, options v1.GetOptions) (result *containerconfv1.PostgreSQLServer, err error) {
+	result = &containerconfv1.PostgreSQLServer{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of
+// PostgreSQLServers that match those selectors.
+func (c *postgreSQLServers) List(opts v1.ListOptions) (result *containerconfv1.PostgreSQLServerList, err error) {
+	result = &containerconfv1.PostgreSQLServerList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested
+// postgreSQLServers.
+func (c *postgreSQLServers) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("postgresqlservers").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
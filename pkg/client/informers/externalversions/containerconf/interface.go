@@ -0,0 +1,30 @@
+// Package containerconf provides access to the informers of each version of
+// the containerconf.de API group.
+package containerconf
+
+import (
+	v1 "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions/containerconf/v1"
+	internalinterfaces "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to each of this group's versions.
+type Interface interface {
+	// V1 provides access to shared informers for resources in V1.
+	V1() v1.Interface
+}
+
+type group struct {
+	internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &group{f, namespace, tweakListOptions}
+}
+
+// V1 returns a new v1.Interface.
+func (g *group) V1() v1.Interface {
+	return v1.New(g.SharedInformerFactory, g.namespace, g.tweakListOptions)
+}
@@ -0,0 +1,43 @@
+// Package v1 provides shared informers for the containerconf.de/v1 API
+// group, hand-written following the informer-gen conventions.
+package v1
+
+import (
+	internalinterfaces "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// Interface provides access to all the informers in this group version.
+type Interface interface {
+	// PostgreSQLConfigs returns a PostgreSQLConfigInformer.
+	PostgreSQLConfigs() PostgreSQLConfigInformer
+	// PostgreSQLServers returns a PostgreSQLServerInformer.
+	PostgreSQLServers() PostgreSQLServerInformer
+	// MySQLConfigs returns a MySQLConfigInformer.
+	MySQLConfigs() MySQLConfigInformer
+}
+
+type version struct {
+	internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// New returns a new Interface.
+func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) Interface {
+	return &version{f, namespace, tweakListOptions}
+}
+
+// PostgreSQLConfigs returns a PostgreSQLConfigInformer.
+func (v *version) PostgreSQLConfigs() PostgreSQLConfigInformer {
+	return &postgreSQLConfigInformer{factory: v.SharedInformerFactory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// PostgreSQLServers returns a PostgreSQLServerInformer.
+func (v *version) PostgreSQLServers() PostgreSQLServerInformer {
+	return &postgreSQLServerInformer{factory: v.SharedInformerFactory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// MySQLConfigs returns a MySQLConfigInformer.
+func (v *version) MySQLConfigs() MySQLConfigInformer {
+	return &mySQLConfigInformer{factory: v.SharedInformerFactory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
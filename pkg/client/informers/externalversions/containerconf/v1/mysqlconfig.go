@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	clientset "github.com/giantswarm/operator-workshop/pkg/client"
+	internalinterfaces "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions/internalinterfaces"
+	listersv1 "github.com/giantswarm/operator-workshop/pkg/client/listers/containerconf/v1"
+)
+
+// MySQLConfigInformer provides access to a shared informer and lister for
+// MySQLConfigs.
+type MySQLConfigInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() listersv1.MySQLConfigLister
+}
+
+type mySQLConfigInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewFilteredMySQLConfigInformer constructs a new informer for MySQLConfig
+// restricted to the given namespace. The tweakListOptions function is
+// applied to the options of every list and watch call and may be nil.
+func NewFilteredMySQLConfigInformer(client clientset.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options apismetav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ContainerconfV1().MySQLConfigs(namespace).List(options)
+			},
+			WatchFunc: func(options apismetav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ContainerconfV1().MySQLConfigs(namespace).Watch(options)
+			},
+		},
+		&containerconfv1.MySQLConfig{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *mySQLConfigInformer) defaultInformer(client clientset.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMySQLConfigInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *mySQLConfigInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&containerconfv1.MySQLConfig{}, f.defaultInformer)
+}
+
+func (f *mySQLConfigInformer) Lister() listersv1.MySQLConfigLister {
+	return listersv1.NewMySQLConfigLister(f.Informer().GetIndexer())
+}
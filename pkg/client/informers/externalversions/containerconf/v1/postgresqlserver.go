@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	clientset "github.com/giantswarm/operator-workshop/pkg/client"
+	internalinterfaces "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions/internalinterfaces"
+	listersv1 "github.com/giantswarm/operator-workshop/pkg/client/listers/containerconf/v1"
+)
+
+// PostgreSQLServerInformer provides access to a shared informer and lister
+// for PostgreSQLServers.
+type PostgreSQLServerInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() listersv1.PostgreSQLServerLister
+}
+
+type postgreSQLServerInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	namespace        string
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewFilteredPostgreSQLServerInformer constructs a new informer for
+// PostgreSQLServer restricted to the given namespace. The tweakListOptions
+// function is applied to the options of every list and watch call and may be
+// nil.
+func NewFilteredPostgreSQLServerInformer(client clientset.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options apismetav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ContainerconfV1().PostgreSQLServers(namespace).List(options)
+			},
+			WatchFunc: func(options apismetav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ContainerconfV1().PostgreSQLServers(namespace).Watch(options)
+			},
+		},
+		&containerconfv1.PostgreSQLServer{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *postgreSQLServerInformer) defaultInformer(client clientset.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredPostgreSQLServerInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *postgreSQLServerInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&containerconfv1.PostgreSQLServer{}, f.defaultInformer)
+}
+
+func (f *postgreSQLServerInformer) Lister() listersv1.PostgreSQLServerLister {
+	return listersv1.NewPostgreSQLServerLister(f.Informer().GetIndexer())
+}
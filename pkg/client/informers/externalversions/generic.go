@@ -0,0 +1,49 @@
+package externalversions
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+)
+
+// GenericInformer is a type of SharedIndexInformer which will locate and
+// delegate to other sharedInformers based on type.
+type GenericInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() cache.GenericLister
+}
+
+type genericInformer struct {
+	informer cache.SharedIndexInformer
+	resource schema.GroupResource
+}
+
+// Informer returns the SharedIndexInformer.
+func (f *genericInformer) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+// Lister returns the GenericLister.
+func (f *genericInformer) Lister() cache.GenericLister {
+	return cache.NewGenericLister(f.Informer().GetIndexer(), f.resource)
+}
+
+// ForResource gives generic access to a shared informer of the matching
+// type.
+func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
+	switch resource {
+	// Group=containerconf.de, Version=v1
+	case containerconfv1.SchemeGroupVersion.WithResource("postgresqlconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Containerconf().V1().PostgreSQLConfigs().Informer()}, nil
+	case containerconfv1.SchemeGroupVersion.WithResource("postgresqlservers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Containerconf().V1().PostgreSQLServers().Informer()}, nil
+	case containerconfv1.SchemeGroupVersion.WithResource("mysqlconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Containerconf().V1().MySQLConfigs().Informer()}, nil
+
+	}
+
+	return nil, fmt.Errorf("no informer found for %v", resource)
+}
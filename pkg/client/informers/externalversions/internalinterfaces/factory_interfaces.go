@@ -0,0 +1,30 @@
+// Package internalinterfaces holds the small interfaces shared between the
+// informer factory and the typed informers so they can reference each other
+// without an import cycle.
+package internalinterfaces
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clientset "github.com/giantswarm/operator-workshop/pkg/client"
+)
+
+// NewInformerFunc constructs a SharedIndexInformer from a clientset and a
+// resync period.
+type NewInformerFunc func(clientset.Interface, time.Duration) cache.SharedIndexInformer
+
+// SharedInformerFactory is a small interface to allow for adding an informer
+// without an import cycle.
+type SharedInformerFactory interface {
+	Start(stopCh <-chan struct{})
+	InformerFor(obj runtime.Object, newFunc NewInformerFunc) cache.SharedIndexInformer
+}
+
+// TweakListOptionsFunc lets a factory adjust the list options of every list
+// and watch call, e.g. to set a label selector.
+type TweakListOptionsFunc func(*apismetav1.ListOptions)
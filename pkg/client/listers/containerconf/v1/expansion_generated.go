@@ -0,0 +1,25 @@
+package v1
+
+// PostgreSQLConfigListerExpansion allows custom methods to be added to
+// PostgreSQLConfigLister.
+type PostgreSQLConfigListerExpansion interface{}
+
+// PostgreSQLConfigNamespaceListerExpansion allows custom methods to be added
+// to PostgreSQLConfigNamespaceLister.
+type PostgreSQLConfigNamespaceListerExpansion interface{}
+
+// PostgreSQLServerListerExpansion allows custom methods to be added to
+// PostgreSQLServerLister.
+type PostgreSQLServerListerExpansion interface{}
+
+// PostgreSQLServerNamespaceListerExpansion allows custom methods to be added
+// to PostgreSQLServerNamespaceLister.
+type PostgreSQLServerNamespaceListerExpansion interface{}
+
+// MySQLConfigListerExpansion allows custom methods to be added to
+// MySQLConfigLister.
+type MySQLConfigListerExpansion interface{}
+
+// MySQLConfigNamespaceListerExpansion allows custom methods to be added to
+// MySQLConfigNamespaceLister.
+type MySQLConfigNamespaceListerExpansion interface{}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+)
+
+// MySQLConfigLister helps list MySQLConfigs.
+type MySQLConfigLister interface {
+	// List lists all MySQLConfigs in the indexer.
+	List(selector labels.Selector) (ret []*containerconfv1.MySQLConfig, err error)
+	// MySQLConfigs returns an object that can list and get MySQLConfigs.
+	MySQLConfigs(namespace string) MySQLConfigNamespaceLister
+	MySQLConfigListerExpansion
+}
+
+// mySQLConfigLister implements the MySQLConfigLister interface.
+type mySQLConfigLister struct {
+	indexer cache.Indexer
+}
+
+// NewMySQLConfigLister returns a new MySQLConfigLister.
+func NewMySQLConfigLister(indexer cache.Indexer) MySQLConfigLister {
+	return &mySQLConfigLister{indexer: indexer}
+}
+
+// List lists all MySQLConfigs in the indexer.
+func (s *mySQLConfigLister) List(selector labels.Selector) (ret []*containerconfv1.MySQLConfig, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.MySQLConfig))
+	})
+	return ret, err
+}
+
+// MySQLConfigs returns an object that can list and get MySQLConfigs.
+func (s *mySQLConfigLister) MySQLConfigs(namespace string) MySQLConfigNamespaceLister {
+	return mySQLConfigNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// MySQLConfigNamespaceLister helps list and get MySQLConfigs.
+type MySQLConfigNamespaceLister interface {
+	// List lists all MySQLConfigs in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*containerconfv1.MySQLConfig, err error)
+	// Get retrieves the MySQLConfig from the indexer for a given namespace
+	// and name.
+	Get(name string) (*containerconfv1.MySQLConfig, error)
+	MySQLConfigNamespaceListerExpansion
+}
+
+// mySQLConfigNamespaceLister implements the MySQLConfigNamespaceLister
+// interface.
+type mySQLConfigNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all MySQLConfigs in the indexer for a given namespace.
+func (s mySQLConfigNamespaceLister) List(selector labels.Selector) (ret []*containerconfv1.MySQLConfig, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.MySQLConfig))
+	})
+	return ret, err
+}
+
+// Get retrieves the MySQLConfig from the indexer for a given namespace and
+// name.
+func (s mySQLConfigNamespaceLister) Get(name string) (*containerconfv1.MySQLConfig, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(containerconfv1.Resource("mysqlconfig"), name)
+	}
+	return obj.(*containerconfv1.MySQLConfig), nil
+}
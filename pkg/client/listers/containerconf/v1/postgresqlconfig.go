@@ -0,0 +1,85 @@
+// Package v1 provides typed listers for the containerconf.de/v1 API group.
+// It is hand-written following the lister-gen conventions so controllers can
+// read objects from informer caches instead of hitting the API server.
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+)
+
+// PostgreSQLConfigLister helps list PostgreSQLConfigs.
+type PostgreSQLConfigLister interface {
+	// List lists all PostgreSQLConfigs in the indexer.
+	List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLConfig, err error)
+	// PostgreSQLConfigs returns an object that can list and get
+	// PostgreSQLConfigs.
+	PostgreSQLConfigs(namespace string) PostgreSQLConfigNamespaceLister
+	PostgreSQLConfigListerExpansion
+}
+
+// postgreSQLConfigLister implements the PostgreSQLConfigLister interface.
+type postgreSQLConfigLister struct {
+	indexer cache.Indexer
+}
+
+// NewPostgreSQLConfigLister returns a new PostgreSQLConfigLister.
+func NewPostgreSQLConfigLister(indexer cache.Indexer) PostgreSQLConfigLister {
+	return &postgreSQLConfigLister{indexer: indexer}
+}
+
+// List lists all PostgreSQLConfigs in the indexer.
+func (s *postgreSQLConfigLister) List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLConfig, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.PostgreSQLConfig))
+	})
+	return ret, err
+}
+
+// PostgreSQLConfigs returns an object that can list and get
+// PostgreSQLConfigs.
+func (s *postgreSQLConfigLister) PostgreSQLConfigs(namespace string) PostgreSQLConfigNamespaceLister {
+	return postgreSQLConfigNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// PostgreSQLConfigNamespaceLister helps list and get PostgreSQLConfigs.
+type PostgreSQLConfigNamespaceLister interface {
+	// List lists all PostgreSQLConfigs in the indexer for a given
+	// namespace.
+	List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLConfig, err error)
+	// Get retrieves the PostgreSQLConfig from the indexer for a given
+	// namespace and name.
+	Get(name string) (*containerconfv1.PostgreSQLConfig, error)
+	PostgreSQLConfigNamespaceListerExpansion
+}
+
+// postgreSQLConfigNamespaceLister implements the
+// PostgreSQLConfigNamespaceLister interface.
+type postgreSQLConfigNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all PostgreSQLConfigs in the indexer for a given namespace.
+func (s postgreSQLConfigNamespaceLister) List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLConfig, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.PostgreSQLConfig))
+	})
+	return ret, err
+}
+
+// Get retrieves the PostgreSQLConfig from the indexer for a given namespace
+// and name.
+func (s postgreSQLConfigNamespaceLister) Get(name string) (*containerconfv1.PostgreSQLConfig, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(containerconfv1.Resource("postgresqlconfig"), name)
+	}
+	return obj.(*containerconfv1.PostgreSQLConfig), nil
+}
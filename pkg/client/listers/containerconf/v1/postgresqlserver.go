@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+)
+
+// PostgreSQLServerLister helps list PostgreSQLServers.
+type PostgreSQLServerLister interface {
+	// List lists all PostgreSQLServers in the indexer.
+	List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLServer, err error)
+	// PostgreSQLServers returns an object that can list and get
+	// PostgreSQLServers.
+	PostgreSQLServers(namespace string) PostgreSQLServerNamespaceLister
+	PostgreSQLServerListerExpansion
+}
+
+// postgreSQLServerLister implements the PostgreSQLServerLister interface.
+type postgreSQLServerLister struct {
+	indexer cache.Indexer
+}
+
+// NewPostgreSQLServerLister returns a new PostgreSQLServerLister.
+func NewPostgreSQLServerLister(indexer cache.Indexer) PostgreSQLServerLister {
+	return &postgreSQLServerLister{indexer: indexer}
+}
+
+// List lists all PostgreSQLServers in the indexer.
+func (s *postgreSQLServerLister) List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLServer, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.PostgreSQLServer))
+	})
+	return ret, err
+}
+
+// PostgreSQLServers returns an object that can list and get
+// PostgreSQLServers.
+func (s *postgreSQLServerLister) PostgreSQLServers(namespace string) PostgreSQLServerNamespaceLister {
+	return postgreSQLServerNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// PostgreSQLServerNamespaceLister helps list and get PostgreSQLServers.
+type PostgreSQLServerNamespaceLister interface {
+	// List lists all PostgreSQLServers in the indexer for a given
+	// namespace.
+	List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLServer, err error)
+	// Get retrieves the PostgreSQLServer from the indexer for a given
+	// namespace and name.
+	Get(name string) (*containerconfv1.PostgreSQLServer, error)
+	PostgreSQLServerNamespaceListerExpansion
+}
+
+// postgreSQLServerNamespaceLister implements the
+// PostgreSQLServerNamespaceLister interface.
+type postgreSQLServerNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all PostgreSQLServers in the indexer for a given namespace.
+func (s postgreSQLServerNamespaceLister) List(selector labels.Selector) (ret []*containerconfv1.PostgreSQLServer, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*containerconfv1.PostgreSQLServer))
+	})
+	return ret, err
+}
+
+// Get retrieves the PostgreSQLServer from the indexer for a given namespace
+// and name.
+func (s postgreSQLServerNamespaceLister) Get(name string) (*containerconfv1.PostgreSQLServer, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(containerconfv1.Resource("postgresqlserver"), name)
+	}
+	return obj.(*containerconfv1.PostgreSQLServer), nil
+}
@@ -0,0 +1,102 @@
+// Package healthz serves the liveness and readiness endpoints backing
+// Kubernetes probes. /healthz reports liveness and is healthy as long as the
+// process serves requests. /readyz runs the registered checks and reports
+// unready when any of them fails.
+package healthz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/buildinfo"
+)
+
+// Check reports whether one dependency is healthy. A non-nil error marks the
+// operator not ready.
+type Check func(ctx context.Context) error
+
+// Config is the health server configuration.
+type Config struct {
+	// Address is the listen address, e.g. ":8088".
+	Address string
+	// Checks are the readiness checks by name. The name appears in the
+	// /readyz response.
+	Checks map[string]Check
+	// Timeout bounds a single check. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Server serves the /healthz and /readyz endpoints.
+type Server struct {
+	address string
+	checks  map[string]Check
+	timeout time.Duration
+}
+
+// New creates a health server.
+func New(config Config) *Server {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = time.Second * 5
+	}
+
+	return &Server{
+		address: config.Address,
+		checks:  config.Checks,
+		timeout: timeout,
+	}
+}
+
+// Run serves the endpoints until the listener fails. It blocks.
+func (s *Server) Run() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
+	err := http.ListenAndServe(s.address, mux)
+	if err != nil {
+		return fmt.Errorf("serving health endpoints: %s", err)
+	}
+
+	return nil
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+	fmt.Fprintln(w, buildinfo.String())
+}
+
+// handleReadyz runs the checks in a stable order and reports 503 with one
+// line per failed check when any of them fails.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		err := s.checks[name](ctx)
+		cancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		for _, f := range failures {
+			fmt.Fprintln(w, f)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
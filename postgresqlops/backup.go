@@ -0,0 +1,88 @@
+package postgresqlops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// DumpDatabase writes a plain-format dump of the database to the writer by
+// shelling out to pg_dump. The pg_dump binary must be available in PATH.
+func (p *PostgreSQLOps) DumpDatabase(ctx context.Context, name string, w io.Writer) error {
+	dbExists, err := p.hasDatabase(name)
+	if err != nil {
+		return fmt.Errorf("checking database exists: %s", err)
+	}
+	if !dbExists {
+		return fmt.Errorf("dumping database: database=%#q does not exist", name)
+	}
+
+	args := []string{
+		"--host", p.config.Host,
+		"--port", fmt.Sprintf("%d", p.config.Port),
+		"--username", p.config.User,
+		"--no-password",
+		name,
+	}
+
+	var stderr bytes.Buffer
+
+	password, err := resolvePassword(p.config)
+	if err != nil {
+		return fmt.Errorf("dumping database: %s", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "pg_dump", args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", password))
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dumping database: %s stderr=%#q", err, stderr.String())
+	}
+
+	return nil
+}
+
+// RestoreDatabase loads a plain-format dump from the reader into the database
+// by shelling out to psql. The psql binary must be available in PATH.
+func (p *PostgreSQLOps) RestoreDatabase(ctx context.Context, name string, r io.Reader) error {
+	dbExists, err := p.hasDatabase(name)
+	if err != nil {
+		return fmt.Errorf("checking database exists: %s", err)
+	}
+	if !dbExists {
+		return fmt.Errorf("restoring database: database=%#q does not exist", name)
+	}
+
+	args := []string{
+		"--host", p.config.Host,
+		"--port", fmt.Sprintf("%d", p.config.Port),
+		"--username", p.config.User,
+		"--no-password",
+		"--dbname", name,
+	}
+
+	var stderr bytes.Buffer
+
+	password, err := resolvePassword(p.config)
+	if err != nil {
+		return fmt.Errorf("restoring database: %s", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "psql", args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", password))
+	cmd.Stdin = r
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("restoring database: %s stderr=%#q", err, stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package postgresqlops
+
+import (
+	"fmt"
+)
+
+// DatabaseSpec describes the desired state of a single managed database.
+type DatabaseSpec struct {
+	Name  string
+	Owner string
+}
+
+// OwnerChange is one drifted database owner, moving from the current owner
+// to the desired one.
+type OwnerChange struct {
+	Name string
+	From string
+	To   string
+}
+
+// DatabaseDiff is the set of changes needed to move the managed databases
+// from the current state to the desired state.
+type DatabaseDiff struct {
+	// Creates are desired databases that don't exist yet.
+	Creates []DatabaseSpec
+	// Updates are existing databases whose owner drifted from the spec.
+	Updates []OwnerChange
+	// Deletes are managed databases without a desired spec.
+	Deletes []Database
+}
+
+// Empty checks if the diff contains no changes.
+func (d DatabaseDiff) Empty() bool {
+	return len(d.Creates) == 0 && len(d.Updates) == 0 && len(d.Deletes) == 0
+}
+
+// Diff computes the changes the operator would perform to reach the desired
+// state, without applying anything. Solutions can log or export it and a
+// plan mode can print it.
+func (p *PostgreSQLOps) Diff(desired []DatabaseSpec) (DatabaseDiff, error) {
+	diff := DatabaseDiff{}
+
+	dbs, err := p.ListDatabases()
+	if err != nil {
+		return DatabaseDiff{}, fmt.Errorf("listing databases: %s", err)
+	}
+
+	current := map[string]Database{}
+	for _, db := range dbs {
+		current[db.Name] = db
+	}
+
+	desiredByName := map[string]DatabaseSpec{}
+	for _, spec := range desired {
+		desiredByName[spec.Name] = spec
+	}
+
+	for _, spec := range desired {
+		db, ok := current[spec.Name]
+		if !ok {
+			diff.Creates = append(diff.Creates, spec)
+			continue
+		}
+
+		if db.Owner != spec.Owner {
+			diff.Updates = append(diff.Updates, OwnerChange{Name: spec.Name, From: db.Owner, To: spec.Owner})
+		}
+	}
+
+	for _, db := range dbs {
+		_, ok := desiredByName[db.Name]
+		if !ok {
+			diff.Deletes = append(diff.Deletes, db)
+		}
+	}
+
+	return diff, nil
+}
+
+// EnsureDatabases reconciles all managed databases towards the desired specs
+// in one pass. Current state is read once and diffed against the specs, so
+// the number of queries doesn't grow with objects times databases. Databases
+// managed by the operator but not present in the specs are deleted.
+func (p *PostgreSQLOps) EnsureDatabases(specs []DatabaseSpec) error {
+	diff, err := p.Diff(specs)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range diff.Creates {
+		err := p.CreateDatabase(spec.Name, spec.Owner)
+		if err != nil {
+			return fmt.Errorf("creating database=%#q: %s", spec.Name, err)
+		}
+	}
+
+	for _, change := range diff.Updates {
+		err := p.ChangeDatabaseOwner(change.Name, change.To)
+		if err != nil {
+			return fmt.Errorf("changing owner of database=%#q: %s", change.Name, err)
+		}
+	}
+
+	for _, db := range diff.Deletes {
+		err := p.DeleteDatabase(db.Name)
+		if err != nil {
+			return fmt.Errorf("deleting database=%#q: %s", db.Name, err)
+		}
+	}
+
+	return nil
+}
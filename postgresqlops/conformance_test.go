@@ -0,0 +1,143 @@
+package postgresqlops
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/operator-workshop/opstest"
+)
+
+// TestPostgreSQLConformance runs the conformance suite against a real
+// Postgres server, e.g. one started with docker. It is skipped unless
+// POSTGRESQLOPS_TEST_HOST is set.
+func TestPostgreSQLConformance(t *testing.T) {
+	host := os.Getenv("POSTGRESQLOPS_TEST_HOST")
+	if host == "" {
+		t.Skip("set POSTGRESQLOPS_TEST_HOST to run against a real Postgres server")
+	}
+
+	port, err := strconv.Atoi(os.Getenv("POSTGRESQLOPS_TEST_PORT"))
+	if err != nil {
+		port = 5432
+	}
+
+	config := Config{
+		Host:     host,
+		Port:     port,
+		User:     os.Getenv("POSTGRESQLOPS_TEST_USER"),
+		Password: os.Getenv("POSTGRESQLOPS_TEST_PASSWORD"),
+	}
+
+	ops, err := New(config)
+	if err != nil {
+		t.Fatalf("creating PostgreSQLOps: %s", err)
+	}
+	defer ops.Close()
+
+	backend := opstest.Backend{
+		CreateDatabase:      ops.CreateDatabase,
+		ChangeDatabaseOwner: ops.ChangeDatabaseOwner,
+		DeleteDatabase:      ops.DeleteDatabase,
+		ListDatabases: func() (map[string]string, error) {
+			dbs, err := ops.ListDatabases()
+			if err != nil {
+				return nil, err
+			}
+			m := map[string]string{}
+			for _, db := range dbs {
+				m[db.Name] = db.Owner
+			}
+			return m, nil
+		},
+	}
+
+	opstest.Run(t, backend)
+}
+
+// TestPostgreSQLSoftDeletePurge trashes a database via the soft-delete mode
+// and purges it. It is skipped unless POSTGRESQLOPS_TEST_HOST is set.
+func TestPostgreSQLSoftDeletePurge(t *testing.T) {
+	host := os.Getenv("POSTGRESQLOPS_TEST_HOST")
+	if host == "" {
+		t.Skip("set POSTGRESQLOPS_TEST_HOST to run against a real Postgres server")
+	}
+
+	port, err := strconv.Atoi(os.Getenv("POSTGRESQLOPS_TEST_PORT"))
+	if err != nil {
+		port = 5432
+	}
+
+	config := Config{
+		Host:       host,
+		Port:       port,
+		User:       os.Getenv("POSTGRESQLOPS_TEST_USER"),
+		Password:   os.Getenv("POSTGRESQLOPS_TEST_PASSWORD"),
+		SoftDelete: true,
+	}
+
+	ops, err := New(config)
+	if err != nil {
+		t.Fatalf("creating PostgreSQLOps: %s", err)
+	}
+	defer ops.Close()
+
+	name := "opstest_purge_db"
+	owner := "opstest_purge_owner"
+
+	// Make sure leftovers from aborted runs don't fail the suite.
+	err = ops.PurgeDeletedDatabases()
+	if err != nil {
+		t.Fatalf("purging leftover databases: %s", err)
+	}
+
+	err = ops.CreateDatabase(name, owner)
+	if err != nil {
+		t.Fatalf("creating database: %s", err)
+	}
+
+	// Trash.
+	err = ops.DeleteDatabase(name)
+	if err != nil {
+		t.Fatalf("trashing database: %s", err)
+	}
+
+	dbs, err := ops.ListDatabases()
+	if err != nil {
+		t.Fatalf("listing databases: %s", err)
+	}
+	for _, db := range dbs {
+		if db.Name == name {
+			t.Fatalf("database=%#q still listed after trashing", name)
+		}
+	}
+
+	trashed, err := ops.ListTrashedDatabases()
+	if err != nil {
+		t.Fatalf("listing trashed databases: %s", err)
+	}
+	found := false
+	for _, db := range trashed {
+		if strings.HasPrefix(db.Name, name+deletedInfix) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("database=%#q not found in trashed databases %v", name, trashed)
+	}
+
+	// Purge.
+	err = ops.PurgeDeletedDatabases()
+	if err != nil {
+		t.Fatalf("purging databases: %s", err)
+	}
+
+	trashed, err = ops.ListTrashedDatabases()
+	if err != nil {
+		t.Fatalf("listing trashed databases: %s", err)
+	}
+	if len(trashed) != 0 {
+		t.Fatalf("trashed databases %v remain after purging", trashed)
+	}
+}
@@ -0,0 +1,57 @@
+package postgresqlops
+
+import (
+	"fmt"
+)
+
+// GrantAllOnDatabase grants all privileges on the database to the user. The
+// user is created if it doesn't exist.
+func (p *PostgreSQLOps) GrantAllOnDatabase(name, user string) error {
+	userExists, err := p.hasUser(user)
+	if err != nil {
+		return fmt.Errorf("checking user exists: %s", err)
+	}
+	if !userExists {
+		p.createUser(user)
+	}
+
+	grant := fmt.Sprintf("GRANT ALL PRIVILEGES ON DATABASE \"%s\" TO \"%s\"", name, user)
+	err = p.exec(grant)
+	if err != nil {
+		return fmt.Errorf("granting all privileges: %s", err)
+	}
+
+	return nil
+}
+
+// RevokePublic revokes all privileges on the database from PUBLIC so only
+// explicitly granted users can connect.
+func (p *PostgreSQLOps) RevokePublic(name string) error {
+	revoke := fmt.Sprintf("REVOKE ALL ON DATABASE \"%s\" FROM PUBLIC", name)
+	err := p.exec(revoke)
+	if err != nil {
+		return fmt.Errorf("revoking public privileges: %s", err)
+	}
+
+	return nil
+}
+
+// GrantReadOnly grants connect privileges on the database to the user. The
+// user is created if it doesn't exist.
+func (p *PostgreSQLOps) GrantReadOnly(name, user string) error {
+	userExists, err := p.hasUser(user)
+	if err != nil {
+		return fmt.Errorf("checking user exists: %s", err)
+	}
+	if !userExists {
+		p.createUser(user)
+	}
+
+	grant := fmt.Sprintf("GRANT CONNECT ON DATABASE \"%s\" TO \"%s\"", name, user)
+	err = p.exec(grant)
+	if err != nil {
+		return fmt.Errorf("granting connect privileges: %s", err)
+	}
+
+	return nil
+}
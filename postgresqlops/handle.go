@@ -0,0 +1,56 @@
+package postgresqlops
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Open returns a handle connected to the given database, e.g. for schema,
+// extension or grant work inside it. Handles are pooled per database and
+// shared between callers, so Open is safe for concurrent use. The handles
+// are closed together with the PostgreSQLOps.
+func (p *PostgreSQLOps) Open(dbname string) (*sql.DB, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.handles == nil {
+		p.handles = map[string]*sql.DB{}
+	}
+
+	db, ok := p.handles[dbname]
+	if ok {
+		return db, nil
+	}
+
+	password, err := resolvePassword(p.config)
+	if err != nil {
+		return nil, fmt.Errorf("opening database=%#q: %s", dbname, err)
+	}
+
+	dsn := connectionString(p.config, password)
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return nil, fmt.Errorf("opening database=%#q: URL DSNs cannot be scoped to a database", dbname)
+	}
+	dsn += fmt.Sprintf(" dbname=%s", dbname)
+
+	db, err = sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening database=%#q: %s", dbname, err)
+	}
+
+	p.handles[dbname] = db
+
+	return db, nil
+}
+
+// closeHandles closes all per-database handles.
+func (p *PostgreSQLOps) closeHandles() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, db := range p.handles {
+		db.Close()
+	}
+	p.handles = nil
+}
@@ -0,0 +1,53 @@
+package postgresqlops
+
+import (
+	"strings"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	statementTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "postgresqlops",
+			Name:      "statement_total",
+			Help:      "Number of SQL statements executed by operation and outcome.",
+		},
+		[]string{"operation", "outcome"},
+	)
+	statementDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "postgresqlops",
+			Name:      "statement_duration_seconds",
+			Help:      "Duration of SQL statements by operation.",
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(statementTotal)
+	prometheus.MustRegister(statementDuration)
+}
+
+// observeStatement records metrics for one executed statement.
+func observeStatement(query string, start time.Time, err error) {
+	operation := "unknown"
+	fields := strings.Fields(query)
+	if len(fields) > 0 {
+		operation = strings.ToLower(fields[0])
+	}
+
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+
+	statementTotal.WithLabelValues(operation, outcome).Inc()
+	statementDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	reconcilemetrics.RecordSQL(time.Since(start))
+}
@@ -0,0 +1,105 @@
+package postgresqlops
+
+import (
+	"fmt"
+)
+
+// migrationsTable is the bookkeeping table created inside managed databases
+// recording which migrations were applied.
+const migrationsTable = "_operator_migrations"
+
+// AppliedMigrations returns the migration names recorded in the bookkeeping
+// table of the database in application order. The table is created when it
+// doesn't exist yet.
+func (p *PostgreSQLOps) AppliedMigrations(name string) ([]string, error) {
+	db, err := p.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %s", err)
+	}
+
+	err = p.ensureMigrationsTable(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`SELECT name FROM "%s" ORDER BY applied_at, name`, migrationsTable))
+	if err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %s", err)
+	}
+	defer rows.Close()
+
+	var applied []string
+	for rows.Next() {
+		var migration string
+		err := rows.Scan(&migration)
+		if err != nil {
+			return nil, fmt.Errorf("listing applied migrations: %s", err)
+		}
+		applied = append(applied, migration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %s", err)
+	}
+
+	return applied, nil
+}
+
+// ApplyMigration executes the migration SQL inside the database and records
+// it in the bookkeeping table. The migration and the record are committed in
+// one transaction, so a failed migration is not recorded as applied.
+func (p *PostgreSQLOps) ApplyMigration(name, migration, migrationSQL string) error {
+	db, err := p.Open(name)
+	if err != nil {
+		return fmt.Errorf("applying migration=%#q: %s", migration, err)
+	}
+
+	err = p.ensureMigrationsTable(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("applying migration=%#q: %s", migration, err)
+	}
+
+	_, err = tx.Exec(migrationSQL)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("applying migration=%#q: %s", migration, err)
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`INSERT INTO "%s" (name) VALUES ($1)`, migrationsTable), migration)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("applying migration=%#q: recording migration: %s", migration, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("applying migration=%#q: %s", migration, err)
+	}
+
+	return nil
+}
+
+// ensureMigrationsTable creates the bookkeeping table inside the database
+// when it doesn't exist yet.
+func (p *PostgreSQLOps) ensureMigrationsTable(name string) error {
+	db, err := p.Open(name)
+	if err != nil {
+		return fmt.Errorf("creating migrations table: %s", err)
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" (
+		name text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`, migrationsTable)
+
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("creating migrations table: %s", err)
+	}
+
+	return nil
+}
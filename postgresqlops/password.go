@@ -0,0 +1,75 @@
+package postgresqlops
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// RotateUserPassword sets a newly generated random password for the user and
+// returns it. The caller is responsible for distributing the new password,
+// e.g. by updating a credentials Secret.
+func (p *PostgreSQLOps) RotateUserPassword(ctx context.Context, user string) (string, error) {
+	userExists, err := p.hasUser(user)
+	if err != nil {
+		return "", fmt.Errorf("checking user exists: %s", err)
+	}
+	if !userExists {
+		return "", fmt.Errorf("rotating password: user=%#q does not exist", user)
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		return "", fmt.Errorf("generating password: %s", err)
+	}
+
+	err = p.alterUserPassword(user, password)
+	if err != nil {
+		return "", fmt.Errorf("rotating password: %s", err)
+	}
+
+	return password, nil
+}
+
+// SetUserPassword sets the password of the user, creating the user when it
+// doesn't exist.
+func (p *PostgreSQLOps) SetUserPassword(user, password string) error {
+	userExists, err := p.hasUser(user)
+	if err != nil {
+		return fmt.Errorf("checking user exists: %s", err)
+	}
+	if !userExists {
+		err := p.createUser(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = p.alterUserPassword(user, password)
+	if err != nil {
+		return fmt.Errorf("setting password: %s", err)
+	}
+
+	return nil
+}
+
+// alterUserPassword sets the user password. The password is escaped for the
+// SQL literal and redacted from logs and the audit trail.
+func (p *PostgreSQLOps) alterUserPassword(user, password string) error {
+	escaped := strings.Replace(password, "'", "''", -1)
+	alterUser := fmt.Sprintf("ALTER USER \"%s\" WITH PASSWORD '%s'", user, escaped)
+	redacted := fmt.Sprintf("ALTER USER \"%s\" WITH PASSWORD '[redacted]'", user)
+	return p.execRedacted(alterUser, redacted)
+}
+
+// generatePassword returns 32 hex characters from a cryptographic source.
+func generatePassword() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,50 @@
+package postgresqlops
+
+import (
+	"fmt"
+)
+
+// QuotaExceededError is returned when creating a database would exceed the
+// configured per-owner quota.
+type QuotaExceededError struct {
+	Owner string
+	Limit int
+}
+
+func (e QuotaExceededError) Error() string {
+	return fmt.Sprintf("owner=%#q already has the maximum of %d databases", e.Owner, e.Limit)
+}
+
+// IsQuotaExceeded checks if the error means the per-owner database quota is
+// exceeded.
+func IsQuotaExceeded(err error) bool {
+	_, ok := err.(QuotaExceededError)
+	return ok
+}
+
+// checkOwnerQuota returns a QuotaExceededError when the owner already has the
+// configured maximum number of managed databases. A zero limit means no
+// quota.
+func (p *PostgreSQLOps) checkOwnerQuota(owner string) error {
+	if p.config.MaxDatabasesPerOwner <= 0 {
+		return nil
+	}
+
+	dbs, err := p.ListDatabases()
+	if err != nil {
+		return fmt.Errorf("listing databases: %s", err)
+	}
+
+	count := 0
+	for _, db := range dbs {
+		if db.Owner == owner {
+			count++
+		}
+	}
+
+	if count >= p.config.MaxDatabasesPerOwner {
+		return QuotaExceededError{Owner: owner, Limit: p.config.MaxDatabasesPerOwner}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package postgresqlops
+
+import (
+	"fmt"
+)
+
+// minServerVersion is the oldest server version the SQL statements in this
+// package are known to work against, in server_version_num format.
+const minServerVersion = 90500
+
+// ServerVersion returns the server version in server_version_num format,
+// e.g. 100004 for PostgreSQL 10.4. The result is cached for the lifetime of
+// the PostgreSQLOps.
+func (p *PostgreSQLOps) ServerVersion() (int, error) {
+	if p.version != 0 {
+		return p.version, nil
+	}
+
+	var version int
+
+	err := p.db.QueryRow("SHOW server_version_num").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("getting server version: %s", err)
+	}
+
+	p.version = version
+
+	return version, nil
+}
+
+// CheckCompatibility verifies the server is recent enough for this package.
+// It produces a clear error instead of letting unsupported servers fail with
+// cryptic SQL errors later.
+func (p *PostgreSQLOps) CheckCompatibility() error {
+	return p.ensureMinVersion(minServerVersion, "operator-workshop")
+}
+
+// ensureMinVersion returns a descriptive error when the server is older than
+// the version a feature requires.
+func (p *PostgreSQLOps) ensureMinVersion(min int, feature string) error {
+	version, err := p.ServerVersion()
+	if err != nil {
+		return err
+	}
+
+	if version < min {
+		return fmt.Errorf("%s requires PostgreSQL >= %d (server_version_num), server reports %d", feature, min, version)
+	}
+
+	return nil
+}
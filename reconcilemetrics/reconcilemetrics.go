@@ -0,0 +1,175 @@
+// Package reconcilemetrics exports Prometheus metrics about reconciliations,
+// so the solutions can be compared quantitatively. The metrics carry a
+// solution label, a single registry serves binaries running any solution.
+package reconcilemetrics
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "reconcile",
+			Name:      "total",
+			Help:      "Number of reconciliations by solution, resource, event and outcome.",
+		},
+		[]string{"solution", "resource", "event", "outcome"},
+	)
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "reconcile",
+			Name:      "duration_seconds",
+			Help:      "Duration of reconciliations by solution, resource and event.",
+		},
+		[]string{"solution", "resource", "event"},
+	)
+	slowTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "reconcile",
+			Name:      "slow_total",
+			Help:      "Number of reconciliations exceeding the slow reconcile threshold.",
+		},
+		[]string{"solution", "resource", "event"},
+	)
+	apiErrorTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "kubernetes_api",
+			Name:      "error_total",
+			Help:      "Number of Kubernetes API errors by solution and operation.",
+		},
+		[]string{"solution", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(reconcileTotal)
+	prometheus.MustRegister(reconcileDuration)
+	prometheus.MustRegister(slowTotal)
+	prometheus.MustRegister(apiErrorTotal)
+}
+
+// slowThresholdNanos is the duration above which a reconcile counts as slow.
+// Zero disables the detection. Accessed atomically.
+var slowThresholdNanos int64
+
+// SetSlowThreshold enables slow reconcile detection, typically right after
+// flag parsing. Zero disables it.
+func SetSlowThreshold(d time.Duration) {
+	atomic.StoreInt64(&slowThresholdNanos, int64(d))
+}
+
+// statementLog records recently finished SQL statements so slow reconciles
+// can report how much of their time was spent in SQL. Attribution is by time
+// window, which is exact for sequential reconciliation and an approximation
+// when reconciliations run concurrently.
+var statementLog struct {
+	sync.Mutex
+	entries []statementEntry
+}
+
+type statementEntry struct {
+	finished time.Time
+	duration time.Duration
+}
+
+// statementLogLimit bounds the statement log. Statements per reconcile are
+// in the tens, so the window comfortably covers a slow reconcile.
+const statementLogLimit = 1024
+
+// RecordSQL records one finished SQL statement. The database ops packages
+// call it for every executed statement.
+func RecordSQL(duration time.Duration) {
+	statementLog.Lock()
+	defer statementLog.Unlock()
+
+	statementLog.entries = append(statementLog.entries, statementEntry{
+		finished: time.Now(),
+		duration: duration,
+	})
+	if len(statementLog.entries) > statementLogLimit {
+		statementLog.entries = statementLog.entries[len(statementLog.entries)-statementLogLimit:]
+	}
+}
+
+// sqlSince sums the time spent in SQL statements finished after start.
+func sqlSince(start time.Time) time.Duration {
+	statementLog.Lock()
+	defer statementLog.Unlock()
+
+	var total time.Duration
+	for _, e := range statementLog.entries {
+		if e.finished.After(start) {
+			total += e.duration
+		}
+	}
+	return total
+}
+
+// Observe records one reconciliation. The outcome label is derived from the
+// status returned by the ensure logic, with variable parts stripped.
+func Observe(solution, resource, event, status string, start time.Time, err error) {
+	duration := time.Since(start)
+
+	reconcileTotal.WithLabelValues(solution, resource, event, outcome(status, err)).Inc()
+	reconcileDuration.WithLabelValues(solution, resource, event).Observe(duration.Seconds())
+
+	// A slow reconcile is broken down into time spent in SQL and the
+	// rest, which is dominated by Kubernetes API access, e.g. reading
+	// referenced Secrets and ConfigMaps.
+	threshold := time.Duration(atomic.LoadInt64(&slowThresholdNanos))
+	if threshold > 0 && duration >= threshold {
+		slowTotal.WithLabelValues(solution, resource, event).Inc()
+
+		sql := sqlSince(start)
+		other := duration - sql
+		if other < 0 {
+			other = 0
+		}
+		logging.Warnf("slow reconcile: total=%s sql=%s other=%s threshold=%s solution=%s resource=%s event=%s", duration, sql, other, threshold, solution, resource, event)
+	}
+}
+
+// APIError records one failed Kubernetes API operation, e.g. "list" or
+// "watch".
+func APIError(solution, operation string) {
+	apiErrorTotal.WithLabelValues(solution, operation).Inc()
+}
+
+// outcome normalizes an ensure status into a low-cardinality label.
+func outcome(status string, err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	switch {
+	case status == "database created":
+		return "created"
+	case status == "database deleted":
+		return "deleted"
+	case status == "already created":
+		return "already-created"
+	case status == "already deleted":
+		return "already-deleted"
+	case status == "charset changed":
+		return "charset-changed"
+	case strings.HasPrefix(status, "owner="):
+		return "owner-changed"
+	case strings.HasPrefix(status, "database renamed"):
+		return "renamed"
+	case strings.HasPrefix(status, "refused"):
+		return "refused"
+	}
+
+	return "other"
+}
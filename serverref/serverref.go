@@ -0,0 +1,197 @@
+// Package serverref resolves PostgreSQLConfig server references into
+// database connections. The referenced Secret holds the connection details
+// under the host, port, user and password keys.
+package serverref
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// secret is the subset of the core Secret object read by the resolver. Data
+// values are base64 encoded in JSON which encoding/json decodes into []byte
+// transparently.
+type secret struct {
+	Data map[string][]byte `json:"data"`
+}
+
+// NewResolver creates a customobject.OpsResolver reading the referenced
+// Secret with the given REST client.
+func NewResolver(restClient rest.Interface) customobject.OpsResolver {
+	return func(ref customobject.SecretRef) (*postgresqlops.PostgreSQLOps, error) {
+		s, err := fetchSecret(restClient, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := opsConfig(s)
+		if err != nil {
+			return nil, fmt.Errorf("reading secret name=%#q: %s", ref.Name, err)
+		}
+
+		ops, err := postgresqlops.New(config)
+		if err != nil {
+			return nil, fmt.Errorf("creating PostgreSQLOps: %s", err)
+		}
+
+		return ops, nil
+	}
+}
+
+// NewServerOps builds a PostgreSQLOps for a PostgreSQLServer spec reading
+// the referenced credentials Secret with the given REST client.
+func NewServerOps(restClient rest.Interface, spec customobject.PostgreSQLServerSpec) (*postgresqlops.PostgreSQLOps, error) {
+	s, err := fetchSecret(restClient, spec.CredentialsSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	user, ok := s.Data["user"]
+	if !ok {
+		return nil, fmt.Errorf("reading secret name=%#q: missing key=%#q", spec.CredentialsSecret.Name, "user")
+	}
+
+	config := postgresqlops.Config{
+		Host:     spec.Host,
+		Port:     spec.Port,
+		User:     string(user),
+		Password: string(s.Data["password"]),
+
+		SSLMode:     spec.SSLMode,
+		SSLRootCert: spec.SSLRootCert,
+	}
+
+	ops, err := postgresqlops.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating PostgreSQLOps: %s", err)
+	}
+
+	return ops, nil
+}
+
+// NewSecretReader creates a customobject.SecretReader reading Secrets with
+// the given REST client.
+func NewSecretReader(restClient rest.Interface) customobject.SecretReader {
+	return func(ref customobject.SecretRef) (map[string][]byte, error) {
+		s, err := fetchSecret(restClient, ref)
+		if err != nil {
+			return nil, err
+		}
+		return s.Data, nil
+	}
+}
+
+// NewSecretWriter creates a customobject.SecretWriter patching the data of
+// the referenced Secret with the given REST client. Existing keys not part
+// of the patch are kept.
+func NewSecretWriter(restClient rest.Interface) customobject.SecretWriter {
+	return func(ref customobject.SecretRef, data map[string][]byte) error {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+
+		body, err := json.Marshal(secret{Data: data})
+		if err != nil {
+			return fmt.Errorf("encoding secret: %s", err)
+		}
+
+		endpoint := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, ref.Name)
+		_, err = restClient.Patch(types.StrategicMergePatchType).AbsPath(endpoint).Body(body).DoRaw()
+		if err != nil {
+			return fmt.Errorf("patching secret endpoint=%s: %s", endpoint, err)
+		}
+
+		return nil
+	}
+}
+
+// configMap is the subset of the core ConfigMap object read by the reader.
+type configMap struct {
+	Data map[string]string `json:"data"`
+}
+
+// NewConfigMapReader creates a customobject.ConfigMapReader reading
+// ConfigMaps with the given REST client.
+func NewConfigMapReader(restClient rest.Interface) customobject.ConfigMapReader {
+	return func(ref customobject.ConfigMapRef) (map[string]string, error) {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+
+		endpoint := fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", namespace, ref.Name)
+		body, err := restClient.Get().AbsPath(endpoint).DoRaw()
+		if err != nil {
+			return nil, fmt.Errorf("requesting config map endpoint=%s: %s", endpoint, err)
+		}
+
+		var c configMap
+		err = json.Unmarshal(body, &c)
+		if err != nil {
+			return nil, fmt.Errorf("decoding config map: %s", err)
+		}
+
+		return c.Data, nil
+	}
+}
+
+// fetchSecret reads the referenced Secret.
+func fetchSecret(restClient rest.Interface, ref customobject.SecretRef) (secret, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, ref.Name)
+	body, err := restClient.Get().AbsPath(endpoint).DoRaw()
+	if err != nil {
+		return secret{}, fmt.Errorf("requesting secret endpoint=%s: %s", endpoint, err)
+	}
+
+	var s secret
+	err = json.Unmarshal(body, &s)
+	if err != nil {
+		return secret{}, fmt.Errorf("decoding secret: %s", err)
+	}
+
+	return s, nil
+}
+
+// opsConfig builds a postgresqlops.Config from the secret data.
+func opsConfig(s secret) (postgresqlops.Config, error) {
+	var config postgresqlops.Config
+
+	host, ok := s.Data["host"]
+	if !ok {
+		return config, fmt.Errorf("missing key=%#q", "host")
+	}
+	config.Host = string(host)
+
+	port, ok := s.Data["port"]
+	if !ok {
+		return config, fmt.Errorf("missing key=%#q", "port")
+	}
+	p, err := strconv.Atoi(string(port))
+	if err != nil {
+		return config, fmt.Errorf("parsing key=%#q: %s", "port", err)
+	}
+	config.Port = p
+
+	user, ok := s.Data["user"]
+	if !ok {
+		return config, fmt.Errorf("missing key=%#q", "user")
+	}
+	config.User = string(user)
+
+	// Password is optional, e.g. for trust authentication.
+	config.Password = string(s.Data["password"])
+
+	return config, nil
+}
@@ -6,16 +6,26 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/pkg/healthz"
 	"github.com/giantswarm/operator-workshop/postgresqlops"
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
+	"github.com/giantswarm/operator-workshop/tracing"
+	"github.com/giantswarm/operator-workshop/watchhealth"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Config struct {
@@ -23,18 +33,106 @@ type Config struct {
 	DBPort     int
 	DBUser     string
 	DBPassword string
+	// DBPasswordFile is a file holding the password, e.g. a mounted
+	// Secret volume. It takes precedence over DBPassword.
+	DBPasswordFile string
+
+	// DryRun logs mutating actions, SQL statements and Kubernetes
+	// writes, instead of performing them.
+	DryRun bool
 
 	K8sInCluster bool
 	K8sServer    string
 	K8sCrtFile   string
 	K8sKeyFile   string
 	K8sCAFile    string
+
+	// K8sToken is a static bearer token sent with every request. It covers
+	// clusters that don't hand out client certificates, e.g. OIDC setups.
+	K8sToken string
+	// K8sTokenFile is a file holding the bearer token. It is re-read for
+	// every request so rotated tokens are picked up.
+	K8sTokenFile string
+	// K8sExecCommand is an exec credential plugin command obtaining a
+	// bearer token, e.g. a cloud provider CLI. The command must print an
+	// ExecCredential object with status.token set.
+	K8sExecCommand string
+
+	// Namespace restricts the operator to objects in one namespace. Empty
+	// watches all namespaces.
+	Namespace string
+	// LabelSelector restricts the operator to objects matching the
+	// selector.
+	LabelSelector string
+
+	// EnableOrphanDeletion enables deleting databases that aren't
+	// referenced by any custom object. Only databases carrying the
+	// managed-by marker are ever considered, still the deletion is
+	// destructive and defaults to off.
+	EnableOrphanDeletion bool
+
+	// HTTPRequestTimeout bounds single requests against the Kubernetes
+	// API. It doesn't apply to watch requests which block server side
+	// until an event occurs.
+	HTTPRequestTimeout time.Duration
+	// HTTPDialTimeout bounds establishing a TCP connection.
+	HTTPDialTimeout time.Duration
+	// HTTPTLSHandshakeTimeout bounds the TLS handshake.
+	HTTPTLSHandshakeTimeout time.Duration
+	// HTTPMaxIdleConns caps idle connections kept open for reuse.
+	HTTPMaxIdleConns int
+
+	// Workers is the number of concurrent object reconciliations. Values
+	// below 1 behave like 1. Operations on the same database name are
+	// serialized regardless of the worker count.
+	Workers int
+
+	// K8sQPS limits queries per second against the Kubernetes API with a
+	// token bucket. Zero disables the limit.
+	K8sQPS float64
+	// K8sBurst is the token bucket size, i.e. the maximum burst of
+	// queries. Values below 1 behave like 1.
+	K8sBurst int
+
+	// Debug logs method, URL, status and latency of every API call,
+	// showing what client-go based solutions hide. Credentials are never
+	// logged.
+	Debug bool
+
+	// HealthAddr is the address the liveness and readiness probe
+	// endpoints are served on. Empty disables the endpoints.
+	HealthAddr string
+	// MetricsAddr is the address the Prometheus metrics endpoint is
+	// served on. Empty disables the endpoint.
+	MetricsAddr string
+
+	// WatchUnhealthyAfter is how long the operator may run without a
+	// live watch stream before the watch health signal reports
+	// unhealthy. Zero uses a 5 minute default.
+	WatchUnhealthyAfter time.Duration
 }
 
+// errWatchExpired signals the server no longer has the requested watch
+// window (410 Gone or an expired resourceVersion error event) and a clean
+// re-list is needed.
+var errWatchExpired = errors.New("watch expired")
+
 type PostgreSQLConfigList struct {
 	Items []*customobject.PostgreSQLConfig `json:"items"`
 }
 
+// objectMetadata is the metadata subset solution1 reads from list and watch
+// payloads.
+type objectMetadata struct {
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+func (m objectMetadata) key() string {
+	return m.Namespace + "/" + m.Name
+}
+
 func Run(ctx context.Context, config Config) error {
 	if config.K8sInCluster {
 		return fmt.Errorf("incluster mode is not supported in solution1")
@@ -46,8 +144,10 @@ func Run(ctx context.Context, config Config) error {
 	}
 
 	// Create Custom Resource Definition.
-	{
-		log.Printf("creating custom resource")
+	if config.DryRun {
+		logging.Infof("dry-run: would create custom resource name=%s", "postgresqlconfigs.containerconf.de")
+	} else {
+		logging.Infof("creating custom resource")
 
 		// crdJson content in YAML format can be found in crd.yaml file.
 		crdJson := `{
@@ -65,7 +165,13 @@ func Run(ctx context.Context, config Config) error {
 					"singular": "postgresqlconfig",
 					"kind": "PostgreSQLConfig",
 					"shortNames": []
-				}
+				},
+				"additionalPrinterColumns": [
+					{"name": "Database", "type": "string", "JSONPath": ".spec.database"},
+					{"name": "Owner", "type": "string", "JSONPath": ".spec.owner"},
+					{"name": "Phase", "type": "string", "JSONPath": ".status.phase"},
+					{"name": "Age", "type": "date", "JSONPath": ".metadata.creationTimestamp"}
+				]
 			}
 		}`
 
@@ -79,7 +185,7 @@ func Run(ctx context.Context, config Config) error {
 		res.Body.Close()
 
 		if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated {
-			log.Printf("creating custom resource: created")
+			logging.Infof("creating custom resource: created")
 		} else {
 			alreadyExists := false
 			errStr := "bad status"
@@ -95,7 +201,7 @@ func Run(ctx context.Context, config Config) error {
 			}
 
 			if alreadyExists {
-				log.Printf("creating custom resource: already exists")
+				logging.Infof("creating custom resource: already exists")
 			} else {
 				return fmt.Errorf("creating custom resource: %s status=%d body=%#q", errStr, res.StatusCode, body)
 			}
@@ -109,9 +215,9 @@ func Run(ctx context.Context, config Config) error {
 		checkInterval := time.Millisecond * 200
 
 		for ; ; attempt++ {
-			log.Printf("checking custom resource readiness attempt=%d", attempt)
+			logging.Infof("checking custom resource readiness attempt=%d", attempt)
 
-			url := config.K8sServer + "/apis/containerconf.de/v1/postgresqlconfigs"
+			url := objectsEndpoint(config)
 			res, err := k8sClient.Get(url)
 			if err != nil {
 				return fmt.Errorf("checking custom resource readiness attempt=%d url=%s: %s", attempt, url, err)
@@ -121,7 +227,7 @@ func Run(ctx context.Context, config Config) error {
 			res.Body.Close()
 
 			if res.StatusCode == http.StatusOK {
-				log.Printf("checking custom resource readiness attempt=%d: ready", attempt)
+				logging.Infof("checking custom resource readiness attempt=%d: ready", attempt)
 				break
 			}
 
@@ -129,7 +235,7 @@ func Run(ctx context.Context, config Config) error {
 				return fmt.Errorf("checking custom resource readiness attempt=%d: bad status status=%d body=%#q", attempt, res.StatusCode, body)
 			}
 
-			log.Printf("checking custom resource readiness attempt=%d: not ready yet", attempt)
+			logging.Infof("checking custom resource readiness attempt=%d: not ready yet", attempt)
 			time.Sleep(checkInterval)
 		}
 	}
@@ -138,10 +244,12 @@ func Run(ctx context.Context, config Config) error {
 	var ops *postgresqlops.PostgreSQLOps
 	{
 		config := postgresqlops.Config{
-			Host:     config.DBHost,
-			Port:     config.DBPort,
-			User:     config.DBUser,
-			Password: config.DBPassword,
+			Host:         config.DBHost,
+			Port:         config.DBPort,
+			User:         config.DBUser,
+			Password:     config.DBPassword,
+			PasswordFile: config.DBPasswordFile,
+			DryRun:       config.DryRun,
 		}
 
 		ops, err = postgresqlops.New(config)
@@ -150,6 +258,11 @@ func Run(ctx context.Context, config Config) error {
 		}
 
 		defer ops.Close()
+
+		err = ops.CheckCompatibility()
+		if err != nil {
+			return fmt.Errorf("checking server compatibility: %s", err)
+		}
 	}
 
 	// Create a resource instance providing reconciliation methods.
@@ -163,34 +276,126 @@ func Run(ctx context.Context, config Config) error {
 	// them. The loop is inifinite, can be cancelled with cancelling the
 	// context.
 	reconciliationInterval := time.Second * 2
-	for {
-		log.Printf("reconciling")
 
-		if ctx.Err() == context.Canceled {
-			log.Printf("reconciling: context cancelled")
-			return nil
+	// API errors back off exponentially instead of retrying at the fixed
+	// interval, and after too many consecutive failures the operator
+	// surfaces a fatal error instead of silently looping forever.
+	backoffMax := time.Minute
+	maxConsecutiveFailures := 10
+	consecutiveFailures := 0
+
+	// sleepCtx returns early when the context is cancelled, so shutdown
+	// doesn't wait out the reconciliation interval. In-flight database
+	// operations always finish, the worker pool is drained every
+	// iteration.
+	sleepCtx := func(d time.Duration) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(d):
 		}
+	}
 
-		url := config.K8sServer + "/apis/containerconf.de/v1/postgresqlconfigs"
-		res, err := k8sClient.Get(url)
-		if err != nil {
-			return fmt.Errorf("reconciling: requesting url=%#q: %s", url, err)
+	backoff := func() time.Duration {
+		d := reconciliationInterval
+		for i := 1; i < consecutiveFailures; i++ {
+			d *= 2
+			if d >= backoffMax {
+				return backoffMax
+			}
 		}
+		return d
+	}
 
-		body := readerToBytesTrimSpace(res.Body)
-		res.Body.Close()
+	// lastSeen holds the last reconciled resourceVersion per object, so
+	// unchanged objects don't trigger redundant database calls in steady
+	// state. The mutex guards it against concurrent workers.
+	lastSeen := map[string]string{}
+	var lastSeenMutex sync.Mutex
 
-		if res.StatusCode != http.StatusOK {
-			log.Printf("reconciling: error client response status status=%d body=%#q", res.StatusCode, body)
-			time.Sleep(reconciliationInterval)
-			continue
+	// dbLocks serializes operations per database name, so two workers
+	// never operate on the same database simultaneously.
+	var dbLocks databaseLocks
+
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// watchTracker counts watch stream recreations and backs the watch
+	// health readiness signal.
+	watchTracker := watchhealth.NewTracker("postgresqlconfigs", config.WatchUnhealthyAfter)
+
+	// Serve the Prometheus metrics endpoint when an address is configured.
+	if config.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			logging.Infof("serving metrics addr=%#q", config.MetricsAddr)
+			err := http.ListenAndServe(config.MetricsAddr, mux)
+			if err != nil {
+				logging.Errorf("serving metrics addr=%#q: %s", config.MetricsAddr, err)
+			}
+		}()
+	}
+
+	// Serve liveness and readiness probes when an address is configured.
+	// Readiness covers the Kubernetes API, the watch stream and the
+	// database server.
+	if config.HealthAddr != "" {
+		s := healthz.New(healthz.Config{
+			Address: config.HealthAddr,
+			Checks: map[string]healthz.Check{
+				"kubernetes": func(ctx context.Context) error {
+					req, err := http.NewRequest("GET", config.K8sServer+"/version", nil)
+					if err != nil {
+						return err
+					}
+					res, err := k8sClient.Do(req.WithContext(ctx))
+					if err != nil {
+						return err
+					}
+					res.Body.Close()
+					if res.StatusCode != http.StatusOK {
+						return fmt.Errorf("unexpected status code=%d", res.StatusCode)
+					}
+					return nil
+				},
+				"watch": func(ctx context.Context) error {
+					if !watchTracker.Healthy() {
+						return fmt.Errorf("no watch stream alive within %s", config.WatchUnhealthyAfter)
+					}
+					return nil
+				},
+				"postgresql": func(ctx context.Context) error {
+					return ops.Ping(ctx)
+				},
+			},
+		})
+		go func() {
+			err := s.Run()
+			if err != nil {
+				logging.Errorf("serving health endpoints addr=%#q: %s", config.HealthAddr, err)
+			}
+		}()
+	}
+
+	for {
+		logging.Infof("reconciling")
+
+		if ctx.Err() == context.Canceled {
+			logging.Infof("reconciling: context cancelled")
+			return nil
 		}
 
-		var configs customobject.PostgreSQLConfigList
-		err = json.Unmarshal(body, &configs)
+		items, metas, err := listObjects(k8sClient, config)
 		if err != nil {
-			log.Printf("reconciling: error unmarshalling postgresqlconfigs list: %s body=%#q", err, body)
-			time.Sleep(reconciliationInterval)
+			reconcilemetrics.APIError("solution1", "list")
+			consecutiveFailures++
+			if consecutiveFailures >= maxConsecutiveFailures {
+				return fmt.Errorf("reconciling: listing objects failed %d times in a row: %s", consecutiveFailures, err)
+			}
+			logging.Errorf("reconciling: error listing objects: %s backoff=%s", err, backoff())
+			sleepCtx(backoff())
 			continue
 		}
 
@@ -198,38 +403,118 @@ func Run(ctx context.Context, config Config) error {
 		// optimised but it isn't really an issue.
 		dbs, err := ops.ListDatabases()
 		if err != nil {
-			log.Printf("reconciling: error listing databases: %s", err)
-			time.Sleep(reconciliationInterval)
+			consecutiveFailures++
+			if consecutiveFailures >= maxConsecutiveFailures {
+				return fmt.Errorf("reconciling: listing databases failed %d times in a row: %s", consecutiveFailures, err)
+			}
+			logging.Errorf("reconciling: error listing databases: %s backoff=%s", err, backoff())
+			sleepCtx(backoff())
 			continue
 		}
 
+		consecutiveFailures = 0
+
+		// Reconcile the database level in bulk first. One listing pass
+		// creates missing databases and aligns drifted owners, instead
+		// of every object querying the server again. Databases without
+		// a matching object are kept in the specs, their deletion stays
+		// with the gated orphan pass below.
+		{
+			specs := []postgresqlops.DatabaseSpec{}
+			matched := map[string]bool{}
+			for _, obj := range items {
+				err := customobject.Validate(*obj)
+				if err != nil {
+					continue
+				}
+				specs = append(specs, postgresqlops.DatabaseSpec{Name: obj.Spec.Database, Owner: obj.Spec.Owner})
+				matched[obj.Spec.Database] = true
+			}
+			for _, db := range dbs {
+				if !matched[db.Name] {
+					specs = append(specs, postgresqlops.DatabaseSpec{Name: db.Name, Owner: db.Owner})
+				}
+			}
+
+			err := ops.EnsureDatabases(specs)
+			if err != nil {
+				logging.Errorf("reconciling: error bulk reconciling databases: %s", err)
+			}
+		}
+
 		// Reconcile updates and memorise valid objects. They will be
-		// used later during deletion.
+		// used later during deletion. Validation stays sequential,
+		// only the database operations run on the worker pool.
 		var validObjs []*customobject.PostgreSQLConfig
 
-		for _, obj := range configs.Items {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, workers)
+
+		for i, obj := range items {
+			meta := metas[i]
+
+			// Skip unchanged objects. The resourceVersion is only
+			// recorded after a successful reconciliation.
+			lastSeenMutex.Lock()
+			seen := meta.ResourceVersion != "" && lastSeen[meta.key()] == meta.ResourceVersion
+			lastSeenMutex.Unlock()
+			if seen {
+				validObjs = append(validObjs, obj)
+				continue
+			}
+
 			err := customobject.Validate(*obj)
 			if err != nil {
-				log.Printf("reconciling: error invalid object: %s obj=%#v", err, *obj)
+				logging.Errorf("reconciling: error invalid object: %s obj=%#v", err, *obj)
 				continue
 			}
 
 			validObjs = append(validObjs, obj)
 
-			status, err := resource.EnsureCreated(obj)
-			if err != nil {
-				log.Printf("reconciling: error: processing update obj=%#v: %s", *obj, err)
-			} else {
-				log.Printf("reconciling: reconciled: %s obj=%#v", status, *obj)
-			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(obj *customobject.PostgreSQLConfig, meta objectMetadata) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				l := dbLocks.get(obj.Spec.Database)
+				l.Lock()
+				defer l.Unlock()
+
+				spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+				reconcileSpan.SetAttribute("solution", "solution1")
+				reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+				reconcileSpan.SetAttribute("event", "update")
+				reconcileSpan.SetAttribute("namespace", meta.Namespace)
+				reconcileSpan.SetAttribute("name", meta.Name)
+
+				start := time.Now()
+				_, ensureSpan := tracing.Start(spanCtx, "ensure")
+				status, err := resource.EnsureCreated(obj)
+				ensureSpan.End(err)
+				reconcileSpan.End(err)
+				reconcilemetrics.Observe("solution1", "postgresqlconfigs", "update", status, start, err)
+				if err != nil {
+					logging.Errorf("reconciling: error: processing update obj=%#v: %s", *obj, err)
+				} else {
+					logging.Infof("reconciling: reconciled: %s obj=%#v", status, *obj)
+					if meta.ResourceVersion != "" {
+						lastSeenMutex.Lock()
+						lastSeen[meta.key()] = meta.ResourceVersion
+						lastSeenMutex.Unlock()
+					}
+				}
+			}(obj, meta)
 		}
 
+		wg.Wait()
+
 		// We still have to delete databases for custom objects that
-		// are gone. This assumes only the operator code does
-		// operataions on the database. Databases that still exists
-		// but aren't referenced by any custom object are subject of
-		// deletion.
-		{
+		// are gone. ListDatabases only returns databases carrying the
+		// managed-by marker, so pre-existing databases are never
+		// touched. The pass is additionally gated behind the
+		// EnableOrphanDeletion flag because deletion is destructive.
+		if config.EnableOrphanDeletion {
 			for _, db := range dbs {
 				processed := false
 
@@ -251,25 +536,288 @@ func Run(ctx context.Context, config Config) error {
 					},
 				}
 
+				spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+				reconcileSpan.SetAttribute("solution", "solution1")
+				reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+				reconcileSpan.SetAttribute("event", "delete")
+				reconcileSpan.SetAttribute("database", db.Name)
+
+				start := time.Now()
+				_, ensureSpan := tracing.Start(spanCtx, "ensure")
 				status, err := resource.EnsureDeleted(obj)
+				ensureSpan.End(err)
+				reconcileSpan.End(err)
+				reconcilemetrics.Observe("solution1", "postgresqlconfigs", "delete", status, start, err)
 				if err != nil {
-					log.Printf("reconciling: error: processing delete obj=%#v: %s", *obj, err)
+					logging.Errorf("reconciling: error: processing delete obj=%#v: %s", *obj, err)
 				} else {
-					log.Printf("reconciling: reconciled: %s obj=%#v", status, *obj)
+					logging.Infof("reconciling: reconciled: %s obj=%#v", status, *obj)
 				}
 			}
 		}
 
-		time.Sleep(reconciliationInterval)
+		// Watch for changes between full passes, demonstrating the
+		// list-then-watch pattern without client-go. A failed watch
+		// falls back to the fixed-interval polling above.
+		err = watchObjects(ctx, k8sClient, config, resource, lastSeen, watchTracker)
+		if err == errWatchExpired {
+			// The server compacted away the watch window, e.g. after
+			// a restart. The recorded resourceVersions are expired
+			// too, forget them and re-list immediately.
+			logging.Warnf("reconciling: watch expired, re-listing")
+			lastSeen = map[string]string{}
+			continue
+		}
+		if err != nil && ctx.Err() != context.Canceled {
+			reconcilemetrics.APIError("solution1", "watch")
+			logging.Warnf("reconciling: error watching, falling back to polling: %s", err)
+		}
+
+		sleepCtx(reconciliationInterval)
 	}
 }
 
-func newHttpClient(config Config) (*http.Client, error) {
-	crt, err := tls.LoadX509KeyPair(config.K8sCrtFile, config.K8sKeyFile)
+// databaseLocks hands out one mutex per database name, serializing
+// concurrent workers touching the same database.
+type databaseLocks struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func (d *databaseLocks) get(name string) *sync.Mutex {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.locks == nil {
+		d.locks = map[string]*sync.Mutex{}
+	}
+	l, ok := d.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		d.locks[name] = l
+	}
+	return l
+}
+
+// objectsEndpoint builds the postgresqlconfigs collection URL honoring the
+// configured namespace scope.
+func objectsEndpoint(config Config) string {
+	if config.Namespace != "" {
+		return config.K8sServer + "/apis/containerconf.de/v1/namespaces/" + config.Namespace + "/postgresqlconfigs"
+	}
+	return config.K8sServer + "/apis/containerconf.de/v1/postgresqlconfigs"
+}
+
+// listObjects fetches the custom objects page by page using limit and
+// continue parameters, so clusters with thousands of objects don't produce
+// unbounded responses. Apiservers predating pagination ignore the limit and
+// return everything in the first page. The returned metadata indexes align
+// with the returned items.
+func listObjects(k8sClient *http.Client, config Config) ([]*customobject.PostgreSQLConfig, []objectMetadata, error) {
+	const pageLimit = 100
+
+	var items []*customobject.PostgreSQLConfig
+	var metas []objectMetadata
+
+	continueToken := ""
+	for {
+		url := fmt.Sprintf("%s?limit=%d", objectsEndpoint(config), pageLimit)
+		if config.LabelSelector != "" {
+			url += "&labelSelector=" + neturl.QueryEscape(config.LabelSelector)
+		}
+		if continueToken != "" {
+			url += "&continue=" + neturl.QueryEscape(continueToken)
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating request url=%#q: %s", url, err)
+		}
+		// Bound the request so a hanging apiserver doesn't stall the
+		// reconciliation loop forever.
+		reqCtx, cancel := context.WithTimeout(context.Background(), config.HTTPRequestTimeout)
+		res, err := k8sClient.Do(req.WithContext(reqCtx))
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("requesting url=%#q: %s", url, err)
+		}
+
+		body := readerToBytesTrimSpace(res.Body)
+		res.Body.Close()
+		cancel()
+
+		if res.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("requesting url=%#q: bad status status=%d body=%#q", url, res.StatusCode, body)
+		}
+
+		var configs customobject.PostgreSQLConfigList
+		err = json.Unmarshal(body, &configs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unmarshalling postgresqlconfigs list: %s body=%#q", err, body)
+		}
+
+		// Decode the list and item metadata separately. The custom
+		// object type doesn't carry metadata, the indexes align with
+		// configs.Items.
+		var metaList struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []struct {
+				Metadata objectMetadata `json:"metadata"`
+			} `json:"items"`
+		}
+		err = json.Unmarshal(body, &metaList)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unmarshalling postgresqlconfigs list metadata: %s body=%#q", err, body)
+		}
+
+		items = append(items, configs.Items...)
+		for i := range configs.Items {
+			var meta objectMetadata
+			if i < len(metaList.Items) {
+				meta = metaList.Items[i].Metadata
+			}
+			metas = append(metas, meta)
+		}
+
+		continueToken = metaList.Metadata.Continue
+		if continueToken == "" {
+			return items, metas, nil
+		}
+	}
+}
+
+// watchObjects issues a streaming watch request and reconciles objects as
+// events arrive. It returns when the stream fails or the context is
+// cancelled, the caller falls back to a full list pass.
+func watchObjects(ctx context.Context, k8sClient *http.Client, config Config, resource *customobject.Resource, lastSeen map[string]string, tracker *watchhealth.Tracker) error {
+	url := objectsEndpoint(config) + "?watch=true"
+	if config.LabelSelector != "" {
+		url += "&labelSelector=" + neturl.QueryEscape(config.LabelSelector)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("creating watch request: %s", err)
+	}
+	req = req.WithContext(ctx)
+
+	res, err := k8sClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting url=%#q: %s", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusGone {
+		return errWatchExpired
+	}
+	if res.StatusCode != http.StatusOK {
+		body := readerToBytesTrimSpace(res.Body)
+		return fmt.Errorf("requesting url=%#q: bad status status=%d body=%#q", url, res.StatusCode, body)
+	}
+
+	tracker.StreamEstablished()
+	defer tracker.StreamBroken()
+
+	// The response is an unbounded stream of JSON watch events, decoded
+	// one event at a time.
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		err := decoder.Decode(&event)
+		if err != nil {
+			return fmt.Errorf("decoding watch event: %s", err)
+		}
+
+		if event.Type == "ERROR" {
+			// An expired resourceVersion arrives as an error event
+			// carrying a Status object.
+			var status struct {
+				Code   int    `json:"code"`
+				Reason string `json:"reason"`
+			}
+			err := json.Unmarshal(event.Object, &status)
+			if err == nil && (status.Code == http.StatusGone || status.Reason == "Expired" || status.Reason == "Gone") {
+				return errWatchExpired
+			}
+			return fmt.Errorf("watching: error event body=%#q", event.Object)
+		}
+
+		var obj customobject.PostgreSQLConfig
+		err = json.Unmarshal(event.Object, &obj)
+		if err != nil {
+			logging.Errorf("watching: error unmarshalling object: %s body=%#q", err, event.Object)
+			continue
+		}
+
+		var objMeta struct {
+			Metadata objectMetadata `json:"metadata"`
+		}
+		err = json.Unmarshal(event.Object, &objMeta)
+		if err != nil {
+			logging.Errorf("watching: error unmarshalling object metadata: %s body=%#q", err, event.Object)
+			continue
+		}
+		meta := objMeta.Metadata
+
+		spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+		reconcileSpan.SetAttribute("solution", "solution1")
+		reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+		reconcileSpan.SetAttribute("namespace", meta.Namespace)
+		reconcileSpan.SetAttribute("name", meta.Name)
+
+		_, validateSpan := tracing.Start(spanCtx, "validate")
+		err = customobject.Validate(obj)
+		validateSpan.End(err)
+		if err != nil {
+			logging.Errorf("watching: error invalid object: %s obj=%#v", err, obj)
+			reconcileSpan.End(err)
+			continue
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			reconcileSpan.SetAttribute("event", "update")
+			start := time.Now()
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := resource.EnsureCreated(&obj)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			reconcilemetrics.Observe("solution1", "postgresqlconfigs", "update", status, start, err)
+			if err != nil {
+				logging.Errorf("watching: error: processing update obj=%#v: %s", obj, err)
+			} else {
+				logging.Infof("watching: reconciled: %s obj=%#v", status, obj)
+				if meta.ResourceVersion != "" {
+					lastSeen[meta.key()] = meta.ResourceVersion
+				}
+			}
+		case "DELETED":
+			reconcileSpan.SetAttribute("event", "delete")
+			start := time.Now()
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := resource.EnsureDeleted(&obj)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			reconcilemetrics.Observe("solution1", "postgresqlconfigs", "delete", status, start, err)
+			if err != nil {
+				logging.Errorf("watching: error: processing delete obj=%#v: %s", obj, err)
+			} else {
+				logging.Infof("watching: reconciled: %s obj=%#v", status, obj)
+				delete(lastSeen, meta.key())
+			}
+		default:
+			reconcileSpan.End(nil)
+			logging.Warnf("watching: unknown event type=%#q", event.Type)
+		}
 	}
+}
 
+func newHttpClient(config Config) (*http.Client, error) {
 	certPool := x509.NewCertPool()
 	caCert, err := ioutil.ReadFile(config.K8sCAFile)
 	if err != nil {
@@ -278,20 +826,222 @@ func newHttpClient(config Config) (*http.Client, error) {
 	certPool.AppendCertsFromPEM(caCert)
 
 	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{crt},
-		RootCAs:      certPool,
+		RootCAs: certPool,
+	}
+
+	// Client certificates are optional when a bearer token is configured.
+	if config.K8sCrtFile != "" || config.K8sKeyFile != "" {
+		crt, err := tls.LoadX509KeyPair(config.K8sCrtFile, config.K8sKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{crt}
 	}
 	tlsConfig.BuildNameToCertificate()
 
+	var transport http.RoundTripper = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   config.HTTPDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: config.HTTPTLSHandshakeTimeout,
+		MaxIdleConns:        config.HTTPMaxIdleConns,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	// The debug transport sits below the token transport, so it measures
+	// pure request latency without rate limiter waits.
+	if config.Debug {
+		transport = &debugTransport{
+			next: transport,
+		}
+	}
+
+	tokenSource := newTokenSource(config)
+	if tokenSource != nil {
+		transport = &bearerTokenTransport{
+			next:  transport,
+			token: tokenSource,
+		}
+	}
+
+	if config.K8sQPS > 0 {
+		burst := float64(config.K8sBurst)
+		if burst < 1 {
+			burst = 1
+		}
+		transport = &rateLimitTransport{
+			next:  transport,
+			qps:   config.K8sQPS,
+			burst: burst,
+		}
+	}
+
 	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
+		Transport: transport,
 	}
 
 	return client, nil
 }
 
+// debugTransport logs method, URL, status and latency of every API call.
+// Headers are never logged so credentials can't leak, and userinfo is
+// redacted from URLs.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		logging.Debugf("debug: %s %s error=%#q elapsed=%s", req.Method, redactURL(req.URL), err, elapsed)
+		return res, err
+	}
+	logging.Debugf("debug: %s %s status=%d elapsed=%s", req.Method, redactURL(req.URL), res.StatusCode, elapsed)
+
+	return res, err
+}
+
+// redactURL strips basic auth userinfo from the URL.
+func redactURL(u *neturl.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	redacted := *u
+	redacted.User = neturl.User("REDACTED")
+	return redacted.String()
+}
+
+// rateLimitTransport applies a token bucket in front of the wrapped
+// transport, protecting small API servers from tight reconcile loops.
+type rateLimitTransport struct {
+	next  http.RoundTripper
+	qps   float64
+	burst float64
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.wait(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("waiting for rate limiter: %s", err)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// wait blocks until a token is available or the request context is
+// cancelled.
+func (t *rateLimitTransport) wait(ctx context.Context) error {
+	for {
+		t.mutex.Lock()
+		now := time.Now()
+		if t.last.IsZero() {
+			// The bucket starts full.
+			t.tokens = t.burst
+		} else {
+			t.tokens += now.Sub(t.last).Seconds() * t.qps
+			if t.tokens > t.burst {
+				t.tokens = t.burst
+			}
+		}
+		t.last = now
+
+		if t.tokens >= 1 {
+			t.tokens--
+			t.mutex.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - t.tokens) / t.qps * float64(time.Second))
+		t.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// bearerTokenTransport adds an Authorization header with a bearer token to
+// every request.
+type bearerTokenTransport struct {
+	next  http.RoundTripper
+	token func() (string, error)
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token()
+	if err != nil {
+		return nil, fmt.Errorf("obtaining bearer token: %s", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.next.RoundTrip(req)
+}
+
+// newTokenSource returns a function obtaining the bearer token, or nil when
+// no token authentication is configured. Precedence is static token, token
+// file, exec credential plugin.
+func newTokenSource(config Config) func() (string, error) {
+	if config.K8sToken != "" {
+		return func() (string, error) {
+			return config.K8sToken, nil
+		}
+	}
+
+	if config.K8sTokenFile != "" {
+		return func() (string, error) {
+			token, err := ioutil.ReadFile(config.K8sTokenFile)
+			if err != nil {
+				return "", fmt.Errorf("reading token file: %s", err)
+			}
+			return string(bytes.TrimSpace(token)), nil
+		}
+	}
+
+	if config.K8sExecCommand != "" {
+		return func() (string, error) {
+			args := strings.Fields(config.K8sExecCommand)
+
+			out, err := exec.Command(args[0], args[1:]...).Output()
+			if err != nil {
+				return "", fmt.Errorf("running exec credential command=%#q: %s", config.K8sExecCommand, err)
+			}
+
+			// The command output is a client.authentication.k8s.io
+			// ExecCredential object.
+			var credential struct {
+				Status struct {
+					Token string `json:"token"`
+				} `json:"status"`
+			}
+			err = json.Unmarshal(out, &credential)
+			if err != nil {
+				return "", fmt.Errorf("decoding exec credential: %s", err)
+			}
+			if credential.Status.Token == "" {
+				return "", fmt.Errorf("decoding exec credential: status.token is not set")
+			}
+
+			return credential.Status.Token, nil
+		}
+	}
+
+	return nil
+}
+
 func readerToBytesTrimSpace(r io.Reader) []byte {
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(r)
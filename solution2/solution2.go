@@ -3,17 +3,32 @@ package solution2
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
 	"time"
 
+	"github.com/giantswarm/operator-workshop/crdutil"
 	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/eventrecorder"
+	"github.com/giantswarm/operator-workshop/leaderelect"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/mysqlops"
+	"github.com/giantswarm/operator-workshop/pkg/healthz"
 	"github.com/giantswarm/operator-workshop/postgresqlops"
-	"k8s.io/apimachinery/pkg/fields"
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
+	"github.com/giantswarm/operator-workshop/serverref"
+	"github.com/giantswarm/operator-workshop/tracing"
+	"github.com/giantswarm/operator-workshop/watchhealth"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -26,6 +41,74 @@ type Config struct {
 	DBPort     int
 	DBUser     string
 	DBPassword string
+	// DBPasswordFile is a file holding the password, e.g. a mounted
+	// Secret volume. It takes precedence over DBPassword.
+	DBPasswordFile string
+
+	// DryRun logs mutating actions, SQL statements and Kubernetes
+	// writes, instead of performing them.
+	DryRun bool
+
+	// MySQLHost enables the MySQLConfig reconciler when set. The workshop
+	// can run against Postgres only.
+	MySQLHost     string
+	MySQLPort     int
+	MySQLUser     string
+	MySQLPassword string
+
+	// NamespacePrefix derives actual database names as
+	// <namespace>_<spec.database> preventing cross-namespace collisions.
+	NamespacePrefix bool
+
+	// Namespace restricts the operator to objects in one namespace. Empty
+	// watches all namespaces.
+	Namespace string
+	// LabelSelector restricts the operator to objects matching the
+	// selector.
+	LabelSelector string
+
+	// Workers is the number of goroutines processing the workqueue.
+	// Values below 1 behave like 1.
+	Workers int
+
+	// ResyncPeriod is how often all known objects are replayed through
+	// the update path even without API events, correcting manual changes
+	// on the database server. Zero uses a 15 second default.
+	ResyncPeriod time.Duration
+
+	// SharedInformer switches from the plain informer to a shared index
+	// informer, demonstrating the delta FIFO fed local cache with
+	// indexers behind client-go generated informers.
+	SharedInformer bool
+
+	// HealthAddr is the address the liveness and readiness probe
+	// endpoints are served on. Empty disables the endpoints.
+	HealthAddr string
+	// MetricsAddr is the address the Prometheus metrics endpoint is
+	// served on. Empty disables the endpoint.
+	MetricsAddr string
+
+	// WatchUnhealthyAfter is how long the operator may run without a
+	// live watch stream before the watch health signal reports
+	// unhealthy. Zero uses a 5 minute default.
+	WatchUnhealthyAfter time.Duration
+
+	// LeaderElect enables leader election, so multiple operator replicas
+	// can run for availability while only the leader reconciles.
+	LeaderElect bool
+	// LeaderElectIdentity is this replica's identity, e.g. the pod name.
+	// It must be unique per replica.
+	LeaderElectIdentity string
+	// LeaderElectLeaseDuration is how long a leadership is valid without
+	// renewal.
+	LeaderElectLeaseDuration time.Duration
+
+	// K8sQPS limits queries per second against the Kubernetes API. Zero
+	// uses the client default.
+	K8sQPS float64
+	// K8sBurst is the maximum burst of queries against the Kubernetes
+	// API. Zero uses the client default.
+	K8sBurst int
 
 	K8sInCluster bool
 	K8sServer    string
@@ -52,7 +135,29 @@ type PostgreSQLConfigList struct {
 	Items []*PostgreSQLConfig `json:"items"`
 }
 
+// MySQLConfig embeds customobject.MySQLConfig adding fields required by
+// runtime.Object interface.
+type MySQLConfig struct {
+	apismetav1.TypeMeta   `json:",inline"`
+	apismetav1.ObjectMeta `json:"metadata,omitempty"`
+
+	customobject.MySQLConfig `json:",inline"`
+}
+
+// MySQLConfigList embeds customobject.MySQLConfigList adding fields required
+// by runtime.Object interface.
+type MySQLConfigList struct {
+	apismetav1.TypeMeta `json:",inline"`
+	apismetav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []*MySQLConfig `json:"items"`
+}
+
 func Run(ctx context.Context, config Config) error {
+	if config.ResyncPeriod == 0 {
+		config.ResyncPeriod = time.Second * 15
+	}
+
 	k8sExtClient, err := newK8sExtClient(config)
 	if err != nil {
 		return fmt.Errorf("creating K8s client: %s", err)
@@ -62,9 +167,49 @@ func Run(ctx context.Context, config Config) error {
 		return fmt.Errorf("creating K8s custom REST client: %s", err)
 	}
 
+	// Wait to become the leader before doing any work, so multiple
+	// replicas can run for availability while only one reconciles.
+	if config.LeaderElect {
+		var elector *leaderelect.Elector
+		{
+			c := leaderelect.Config{
+				RESTClient:    k8sExtClient.Apiextensions().RESTClient(),
+				Namespace:     config.Namespace,
+				Name:          "operator-workshop-solution2",
+				Identity:      config.LeaderElectIdentity,
+				LeaseDuration: config.LeaderElectLeaseDuration,
+			}
+			elector, err = leaderelect.New(c)
+			if err != nil {
+				return fmt.Errorf("creating elector: %s", err)
+			}
+		}
+
+		logging.Infof("waiting to become leader identity=%#q", config.LeaderElectIdentity)
+		lostCh, err := elector.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("electing leader: %s", err)
+		}
+		logging.Infof("became leader identity=%#q", config.LeaderElectIdentity)
+
+		// Stop all work when the leadership is lost, another replica
+		// takes over.
+		electionCtx, electionCancel := context.WithCancel(ctx)
+		defer electionCancel()
+		go func() {
+			select {
+			case <-lostCh:
+				logging.Warnf("leadership lost, shutting down")
+				electionCancel()
+			case <-electionCtx.Done():
+			}
+		}()
+		ctx = electionCtx
+	}
+
 	// Create Custom Resource Definition.
 	{
-		log.Printf("creating custom resource")
+		logging.Infof("creating custom resource")
 
 		// crdJson content in YAML format can be found in crd.yaml file.
 		crd := &apiextensionsv1beta1.CustomResourceDefinition{
@@ -88,47 +233,103 @@ func Run(ctx context.Context, config Config) error {
 			},
 		}
 
-		_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
-		if apierrors.IsAlreadyExists(err) {
-			log.Printf("creating custom resource: already exists")
-		} else if err != nil {
-			return fmt.Errorf("creating custom resource: %s", err)
+		if config.DryRun {
+			logging.Infof("dry-run: would create custom resource name=%s", crd.ObjectMeta.Name)
 		} else {
-			log.Printf("creating custom resource: created")
+			_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
+			if apierrors.IsAlreadyExists(err) {
+				logging.Infof("creating custom resource: already exists")
+			} else if err != nil {
+				return fmt.Errorf("creating custom resource: %s", err)
+			} else {
+				logging.Infof("creating custom resource: created")
+			}
 		}
 	}
 
-	// Wait for the Custom Resource to be ready.
+	// Create MySQLConfig Custom Resource Definition.
 	{
+		logging.Infof("creating mysql custom resource")
+
+		crd := &apiextensionsv1beta1.CustomResourceDefinition{
+			TypeMeta: apismetav1.TypeMeta{
+				APIVersion: "apiextensions.k8s.io/v1beta1",
+				Kind:       "CustomResourceDefinition",
+			},
+			ObjectMeta: apismetav1.ObjectMeta{
+				Name: "mysqlconfigs.containerconf.de",
+			},
+			Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+				Group:   "containerconf.de",
+				Version: "v1",
+				Scope:   apiextensionsv1beta1.NamespaceScoped,
+				Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+					Plural:     "mysqlconfigs",
+					Singular:   "mysqlconfig",
+					Kind:       "MySQLConfig",
+					ShortNames: []string{},
+				},
+			},
+		}
+
+		if config.DryRun {
+			logging.Infof("dry-run: would create custom resource name=%s", crd.ObjectMeta.Name)
+		} else {
+			_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
+			if apierrors.IsAlreadyExists(err) {
+				logging.Infof("creating mysql custom resource: already exists")
+			} else if err != nil {
+				return fmt.Errorf("creating mysql custom resource: %s", err)
+			} else {
+				logging.Infof("creating mysql custom resource: created")
+			}
+		}
+	}
+
+	// Wait for the Custom Resources to be ready.
+	for _, endpoint := range []string{"/apis/containerconf.de/v1/postgresqlconfigs", "/apis/containerconf.de/v1/mysqlconfigs"} {
 		attempt := 1
 		maxAttempts := 10
 		checkInterval := time.Millisecond * 200
 
 		for ; ; attempt++ {
-			log.Printf("checking custom resource readiness attempt=%d", attempt)
+			logging.Infof("checking custom resource readiness endpoint=%s attempt=%d", endpoint, attempt)
 
-			endpoint := "/apis/containerconf.de/v1/postgresqlconfigs"
 			err := k8sExtClient.Apiextensions().RESTClient().Get().AbsPath(endpoint).Do().Error()
 			if err != nil && attempt == maxAttempts {
 				return fmt.Errorf("checking custom resource readiness attempt=%d: %s", attempt, err)
 			} else if err != nil {
-				log.Printf("checking custom resource readiness attempt=%d: not ready yet", attempt)
+				logging.Infof("checking custom resource readiness attempt=%d: not ready yet", attempt)
 				time.Sleep(checkInterval)
 			} else {
-				log.Printf("checking custom resource readiness attempt=%d: ready", attempt)
+				logging.Infof("checking custom resource readiness attempt=%d: ready", attempt)
 				break
 			}
 		}
 	}
 
+	// Install kubectl get columns. Best effort, apiservers predating
+	// additionalPrinterColumns drop them. Skipped in dry-run mode.
+	for _, name := range []string{"postgresqlconfigs.containerconf.de", "mysqlconfigs.containerconf.de"} {
+		if config.DryRun {
+			continue
+		}
+		err := crdutil.EnsurePrinterColumns(k8sExtClient.Apiextensions().RESTClient(), name, crdutil.ConfigColumns)
+		if err != nil {
+			logging.Warnf("installing printer columns name=%s: %s", name, err)
+		}
+	}
+
 	// Create PostgreSQLOps.
 	var ops *postgresqlops.PostgreSQLOps
 	{
 		config := postgresqlops.Config{
-			Host:     config.DBHost,
-			Port:     config.DBPort,
-			User:     config.DBUser,
-			Password: config.DBPassword,
+			Host:         config.DBHost,
+			Port:         config.DBPort,
+			User:         config.DBUser,
+			Password:     config.DBPassword,
+			PasswordFile: config.DBPasswordFile,
+			DryRun:       config.DryRun,
 		}
 
 		ops, err = postgresqlops.New(config)
@@ -137,50 +338,129 @@ func Run(ctx context.Context, config Config) error {
 		}
 
 		defer ops.Close()
+
+		err = ops.CheckCompatibility()
+		if err != nil {
+			return fmt.Errorf("checking server compatibility: %s", err)
+		}
 	}
 
-	// Create a resource instance providing reconciliation methods.
+	// Create a resource instance providing reconciliation methods. The
+	// resolver lets objects target other Postgres servers via
+	// Spec.ServerRef.
 	var resource *customobject.Resource
 	{
-		resource = customobject.NewResource(ops)
+		resolver := serverref.NewResolver(k8sExtClient.Apiextensions().RESTClient())
+		resource = customobject.NewResourceWithResolver(ops, resolver)
+		resource.SetSecretReader(serverref.NewSecretReader(k8sExtClient.Apiextensions().RESTClient()))
+		resource.SetConfigMapReader(serverref.NewConfigMapReader(k8sExtClient.Apiextensions().RESTClient()))
+	}
+
+	// Create an event recorder making reconciliation outcomes visible via
+	// kubectl describe.
+	var recorder *eventrecorder.Recorder
+	{
+		c := eventrecorder.Config{
+			RESTClient: k8sExtClient.Apiextensions().RESTClient(),
+			Component:  "operator-workshop-solution2",
+			DryRun:     config.DryRun,
+		}
+		recorder = eventrecorder.New(c)
 	}
 
 	// Create reconciliation events handler functions.
 
-	onUpdateFunc := func(obj interface{}) {
+	onUpdateFunc := func(obj interface{}) error {
 		postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
 		if !ok {
-			log.Printf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+		}
+		if customobject.IsPaused(postgreSQLConfig.Annotations) {
+			logging.Debugf("reconciling: paused obj=%#v", postgreSQLConfig.PostgreSQLConfig)
+			return nil
 		}
+		spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+		reconcileSpan.SetAttribute("solution", "solution2")
+		reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+		reconcileSpan.SetAttribute("event", "update")
+		reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+		reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+		_, validateSpan := tracing.Start(spanCtx, "validate")
 		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+		validateSpan.End(err)
 		if err != nil {
-			log.Printf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			logging.Errorf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+			reconcileSpan.End(err)
+			return nil
+		}
+
+		co := &postgreSQLConfig.PostgreSQLConfig
+		if config.NamespacePrefix {
+			co = customobject.NamespacePrefixed(co, postgreSQLConfig.Namespace)
 		}
 
-		status, err := resource.EnsureCreated(&postgreSQLConfig.PostgreSQLConfig)
+		start := time.Now()
+		_, ensureSpan := tracing.Start(spanCtx, "ensure")
+		status, err := resource.EnsureCreated(co)
+		ensureSpan.End(err)
+		reconcileSpan.End(err)
+		reconcilemetrics.Observe("solution2", "postgresqlconfigs", "update", status, start, err)
 		if err != nil {
-			log.Printf("reconciling: error: processing update obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
-		} else {
-			log.Printf("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			logging.Errorf("reconciling: error: processing update obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+			return err
 		}
+
+		logging.Infof("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+		recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+		return nil
 	}
 
-	onDeleteFunc := func(obj interface{}) {
+	onDeleteFunc := func(obj interface{}) error {
 		postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
 		if !ok {
-			log.Printf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+		}
+		if customobject.IsPaused(postgreSQLConfig.Annotations) {
+			logging.Debugf("reconciling: paused obj=%#v", postgreSQLConfig.PostgreSQLConfig)
+			return nil
 		}
+		spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+		reconcileSpan.SetAttribute("solution", "solution2")
+		reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+		reconcileSpan.SetAttribute("event", "delete")
+		reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+		reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+		_, validateSpan := tracing.Start(spanCtx, "validate")
 		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+		validateSpan.End(err)
 		if err != nil {
-			log.Printf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			logging.Errorf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
 		}
 
-		status, err := resource.EnsureDeleted(&postgreSQLConfig.PostgreSQLConfig)
+		co := &postgreSQLConfig.PostgreSQLConfig
+		if config.NamespacePrefix {
+			co = customobject.NamespacePrefixed(co, postgreSQLConfig.Namespace)
+		}
+
+		start := time.Now()
+		_, ensureSpan := tracing.Start(spanCtx, "ensure")
+		status, err := resource.EnsureDeleted(co)
+		ensureSpan.End(err)
+		reconcileSpan.End(err)
+		reconcilemetrics.Observe("solution2", "postgresqlconfigs", "delete", status, start, err)
 		if err != nil {
-			log.Printf("reconciling: error: processing delete obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
-		} else {
-			log.Printf("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			logging.Errorf("reconciling: error: processing delete obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+			return err
 		}
+
+		logging.Infof("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+		recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+		return nil
 	}
 
 	// Start reconciliation loop.
@@ -188,21 +468,549 @@ func Run(ctx context.Context, config Config) error {
 	// In Giant Swarm we believe that you should treat Added and Updated as
 	// the same thing. Otherwise you most likely don't write a correct
 	// reconciliation.
-	handler := cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { onUpdateFunc(obj) },
-		UpdateFunc: func(oldObj, newObj interface{}) { onUpdateFunc(newObj) },
-		DeleteFunc: func(obj interface{}) { onDeleteFunc(obj) },
+	//
+	// Events only enqueue object keys. The workqueue deduplicates keys
+	// and rate limits processing, the workers call the reconciliation and
+	// requeue failed keys with per-key exponential backoff.
+	pgQueueController := newQueueController(onUpdateFunc, onDeleteFunc)
+	handler := pgQueueController.handler()
+
+	// watchTracker counts watch stream recreations and backs the watch
+	// health readiness signal.
+	watchTracker := watchhealth.NewTracker("postgresqlconfigs", config.WatchUnhealthyAfter)
+
+	// Serve the Prometheus metrics endpoint when an address is configured.
+	if config.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			logging.Infof("serving metrics addr=%#q", config.MetricsAddr)
+			err := http.ListenAndServe(config.MetricsAddr, mux)
+			if err != nil {
+				logging.Errorf("serving metrics addr=%#q: %s", config.MetricsAddr, err)
+			}
+		}()
 	}
 
-	listWatch := cache.NewListWatchFromClient(k8sCustomRestClient, "postgresqlconfigs", "", fields.Everything())
+	// Serve liveness and readiness probes when an address is configured.
+	// Readiness covers the Kubernetes API, the watch stream and the
+	// database server.
+	if config.HealthAddr != "" {
+		s := healthz.New(healthz.Config{
+			Address: config.HealthAddr,
+			Checks: map[string]healthz.Check{
+				"kubernetes": func(ctx context.Context) error {
+					_, err := k8sCustomRestClient.Get().AbsPath("/version").DoRaw()
+					return err
+				},
+				"watch": func(ctx context.Context) error {
+					if !watchTracker.Healthy() {
+						return fmt.Errorf("no watch stream alive within %s", config.WatchUnhealthyAfter)
+					}
+					return nil
+				},
+				"postgresql": func(ctx context.Context) error {
+					return ops.Ping(ctx)
+				},
+			},
+		})
+		go func() {
+			err := s.Run()
+			if err != nil {
+				logging.Errorf("serving health endpoints addr=%#q: %s", config.HealthAddr, err)
+			}
+		}()
+	}
+
+	listWatch := newListWatch(k8sCustomRestClient, "postgresqlconfigs", config, watchTracker)
+
+	// Reconcile pre-existing objects through the Added path before the
+	// informer starts, so objects created while the operator was down are
+	// handled even if the first informer sync is delayed. EnsureCreated
+	// is idempotent, reconciling an object twice is harmless.
+	{
+		v, err := listWatch.List(apismetav1.ListOptions{})
+		if err != nil {
+			logging.Errorf("reconciling: error listing objects for bootstrap: %s", err)
+		} else if list, ok := v.(*PostgreSQLConfigList); ok {
+			for _, item := range list.Items {
+				onUpdateFunc(item)
+			}
+		} else {
+			logging.Warnf("reconciling: wrong bootstrap list type %T, want %T", v, &PostgreSQLConfigList{})
+		}
+	}
+
+	var store cache.Store
+	var informer cache.Controller
+	if config.SharedInformer {
+		sharedInformer := cache.NewSharedIndexInformer(listWatch, &PostgreSQLConfig{}, config.ResyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		sharedInformer.AddEventHandler(handler)
+		store = sharedInformer.GetStore()
+		informer = sharedInformer
+	} else {
+		store, informer = cache.NewInformer(listWatch, &PostgreSQLConfig{}, config.ResyncPeriod, handler)
+	}
+	pgQueueController.store = store
+
+	// Create the MySQL reconciliation when a MySQL server is configured.
+	// The workshop can run against Postgres only.
+	var mysqlInformer cache.Controller
+	var mysqlQueueController *queueController
+	if config.MySQLHost != "" {
+		var mysqlOps *mysqlops.MySQLOps
+		{
+			c := mysqlops.Config{
+				Host:     config.MySQLHost,
+				Port:     config.MySQLPort,
+				User:     config.MySQLUser,
+				Password: config.MySQLPassword,
+				DryRun:   config.DryRun,
+			}
+
+			mysqlOps, err = mysqlops.New(c)
+			if err != nil {
+				return fmt.Errorf("creating MySQLOps: %s", err)
+			}
+
+			defer mysqlOps.Close()
+		}
+
+		mysqlResource := customobject.NewMySQLResource(mysqlOps)
+
+		onMySQLUpdateFunc := func(obj interface{}) error {
+			mySQLConfig, ok := obj.(*MySQLConfig)
+			if !ok {
+				logging.Warnf("reconciling: wrong type %T, want %T", obj, mySQLConfig)
+			}
+			if customobject.IsPaused(mySQLConfig.Annotations) {
+				logging.Debugf("reconciling: paused obj=%#v", mySQLConfig.MySQLConfig)
+				return nil
+			}
+			spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+			reconcileSpan.SetAttribute("solution", "solution2")
+			reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+			reconcileSpan.SetAttribute("event", "update")
+			reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+			reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+			_, validateSpan := tracing.Start(spanCtx, "validate")
+			err := customobject.ValidateMySQL(mySQLConfig.MySQLConfig)
+			validateSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error invalid obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+				reconcileSpan.End(err)
+				return nil
+			}
+
+			start := time.Now()
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := mysqlResource.EnsureCreated(&mySQLConfig.MySQLConfig)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			reconcilemetrics.Observe("solution2", "mysqlconfigs", "update", status, start, err)
+			if err != nil {
+				logging.Errorf("reconciling: error: processing update obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+				return err
+			}
+
+			logging.Infof("reconciling: reconciled: %s obj=%#v", status, mySQLConfig.MySQLConfig)
+			recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+			return nil
+		}
+
+		onMySQLDeleteFunc := func(obj interface{}) error {
+			mySQLConfig, ok := obj.(*MySQLConfig)
+			if !ok {
+				logging.Warnf("reconciling: wrong type %T, want %T", obj, mySQLConfig)
+			}
+			if customobject.IsPaused(mySQLConfig.Annotations) {
+				logging.Debugf("reconciling: paused obj=%#v", mySQLConfig.MySQLConfig)
+				return nil
+			}
+			spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+			reconcileSpan.SetAttribute("solution", "solution2")
+			reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+			reconcileSpan.SetAttribute("event", "delete")
+			reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+			reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+			_, validateSpan := tracing.Start(spanCtx, "validate")
+			err := customobject.ValidateMySQL(mySQLConfig.MySQLConfig)
+			validateSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error invalid obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+			}
+
+			start := time.Now()
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := mysqlResource.EnsureDeleted(&mySQLConfig.MySQLConfig)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			reconcilemetrics.Observe("solution2", "mysqlconfigs", "delete", status, start, err)
+			if err != nil {
+				logging.Errorf("reconciling: error: processing delete obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+				return err
+			}
+
+			logging.Infof("reconciling: reconciled: %s obj=%#v", status, mySQLConfig.MySQLConfig)
+			recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+			return nil
+		}
+
+		mysqlQueueController = newQueueController(onMySQLUpdateFunc, onMySQLDeleteFunc)
+		mysqlHandler := mysqlQueueController.handler()
+
+		mysqlWatchTracker := watchhealth.NewTracker("mysqlconfigs", config.WatchUnhealthyAfter)
+
+		mysqlListWatch := newListWatch(k8sCustomRestClient, "mysqlconfigs", config, mysqlWatchTracker)
 
-	_, informer := cache.NewInformer(listWatch, &PostgreSQLConfig{}, time.Second*15, handler)
+		// Reconcile pre-existing objects before the informer starts,
+		// mirroring the PostgreSQL bootstrap above.
+		{
+			v, err := mysqlListWatch.List(apismetav1.ListOptions{})
+			if err != nil {
+				logging.Errorf("reconciling: error listing objects for bootstrap: %s", err)
+			} else if list, ok := v.(*MySQLConfigList); ok {
+				for _, item := range list.Items {
+					onMySQLUpdateFunc(item)
+				}
+			} else {
+				logging.Warnf("reconciling: wrong bootstrap list type %T, want %T", v, &MySQLConfigList{})
+			}
+		}
+
+		var mysqlStore cache.Store
+		if config.SharedInformer {
+			sharedInformer := cache.NewSharedIndexInformer(mysqlListWatch, &MySQLConfig{}, config.ResyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			sharedInformer.AddEventHandler(mysqlHandler)
+			mysqlStore = sharedInformer.GetStore()
+			mysqlInformer = sharedInformer
+		} else {
+			mysqlStore, mysqlInformer = cache.NewInformer(mysqlListWatch, &MySQLConfig{}, config.ResyncPeriod, mysqlHandler)
+		}
+		mysqlQueueController.store = mysqlStore
+	}
 
+	if mysqlInformer != nil {
+		go mysqlInformer.Run(ctx.Done())
+		go mysqlQueueController.run(ctx, config.Workers)
+	}
+
+	go pgQueueController.run(ctx, config.Workers)
 	informer.Run(ctx.Done())
 
+	// The informers stopped delivering events. Wait for the workers to
+	// drain in-flight reconciliations before the deferred Close tears
+	// down the database pools, bounded so a stuck reconcile can't block
+	// shutdown forever.
+	logging.Infof("shutting down: draining in-flight reconciliations")
+	drainTimeout := time.After(time.Second * 30)
+	for _, q := range []*queueController{pgQueueController, mysqlQueueController} {
+		if q == nil {
+			continue
+		}
+		select {
+		case <-q.doneCh:
+		case <-drainTimeout:
+			logging.Warnf("shutting down: timed out draining reconciliations")
+		}
+	}
+
 	return nil
 }
 
+// queueController decouples informer events from reconciliation. Events
+// enqueue object keys into a rate-limited workqueue with deduplication,
+// worker goroutines look the objects up in the informer store and call the
+// handlers. Failed keys are requeued with per-key exponential backoff.
+//
+// The key dedup together with the store lookup also coalesces bursts of
+// Modified events, the worker always reconciles the newest version of an
+// object.
+type queueController struct {
+	queue      workqueue.RateLimitingInterface
+	store      cache.Store
+	updateFunc func(obj interface{}) error
+	deleteFunc func(obj interface{}) error
+
+	// mutex guards deleted, holding objects removed from the store before
+	// the worker processed their keys.
+	mutex   sync.Mutex
+	deleted map[string]interface{}
+
+	// doneCh is closed when all workers exited.
+	doneCh chan struct{}
+}
+
+func newQueueController(updateFunc, deleteFunc func(obj interface{}) error) *queueController {
+	return &queueController{
+		queue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		updateFunc: updateFunc,
+		deleteFunc: deleteFunc,
+		deleted:    map[string]interface{}{},
+		doneCh:     make(chan struct{}),
+	}
+}
+
+// handler returns informer event handlers enqueueing object keys.
+func (q *queueController) handler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    q.enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) { q.enqueue(newObj) },
+		DeleteFunc: q.enqueueDelete,
+	}
+}
+
+func (q *queueController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		logging.Errorf("reconciling: error creating key for %T: %s", obj, err)
+		return
+	}
+	q.queue.Add(key)
+}
+
+func (q *queueController) enqueueDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		logging.Errorf("reconciling: error creating key for %T: %s", obj, err)
+		return
+	}
+
+	// A watch disconnect can replace the object with a tombstone carrying
+	// the last known state. Unwrap it so the delete handler always sees
+	// the object type it expects.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	// The store no longer holds the object when the worker runs, remember
+	// it keyed the same way.
+	q.mutex.Lock()
+	q.deleted[key] = obj
+	q.mutex.Unlock()
+
+	q.queue.Add(key)
+}
+
+// run starts the workers and blocks until the context is cancelled and all
+// in-flight work finished.
+func (q *queueController) run(ctx context.Context, workers int) {
+	defer close(q.doneCh)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	go func() {
+		<-ctx.Done()
+		q.queue.ShutDown()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for q.processNext() {
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *queueController) processNext() bool {
+	v, shutdown := q.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer q.queue.Done(v)
+
+	key := v.(string)
+
+	// A panicking reconciliation must not crash the whole operator, other
+	// objects can still be processed. The panic is surfaced as an error,
+	// so the key goes through the regular requeue path.
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+		return q.process(key)
+	}()
+	if err != nil {
+		logging.Warnf("reconciling: error processing key=%#q, requeueing: %s", key, err)
+		q.queue.AddRateLimited(v)
+		return true
+	}
+
+	q.queue.Forget(v)
+	return true
+}
+
+func (q *queueController) process(key string) error {
+	obj, exists, err := q.store.GetByKey(key)
+	if err != nil {
+		return fmt.Errorf("getting key=%#q from store: %s", key, err)
+	}
+
+	if exists {
+		q.mutex.Lock()
+		delete(q.deleted, key)
+		q.mutex.Unlock()
+		return q.updateFunc(obj)
+	}
+
+	q.mutex.Lock()
+	deletedObj, ok := q.deleted[key]
+	q.mutex.Unlock()
+	if !ok {
+		// Created and deleted before the worker ran.
+		return nil
+	}
+
+	err = q.deleteFunc(deletedObj)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	delete(q.deleted, key)
+	q.mutex.Unlock()
+
+	return nil
+}
+
+// newListWatch builds a ListWatch for the resource honoring the configured
+// namespace scope and label selector.
+func newListWatch(restClient rest.Interface, resource string, config Config, tracker *watchhealth.Tracker) *cache.ListWatch {
+	newRequest := func(prefix ...string) *rest.Request {
+		req := restClient.Get()
+		if len(prefix) > 0 {
+			req = req.Prefix(prefix...)
+		}
+		if config.Namespace != "" {
+			req = req.Namespace(config.Namespace)
+		}
+		req = req.Resource(resource)
+		if config.LabelSelector != "" {
+			req = req.Param("labelSelector", config.LabelSelector)
+		}
+		return req
+	}
+
+	// lastResourceVersion remembers where the previous list or watch
+	// stopped, so recreated streams resume there instead of missing or
+	// replaying events. Bookmark events keep it fresh during quiet
+	// periods.
+	var mutex sync.Mutex
+	lastResourceVersion := ""
+
+	storeResourceVersion := func(obj runtime.Object) {
+		accessor, err := apimeta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		v := accessor.GetResourceVersion()
+		if v == "" {
+			return
+		}
+		mutex.Lock()
+		lastResourceVersion = v
+		mutex.Unlock()
+	}
+
+	return &cache.ListWatch{
+		ListFunc: func(options apismetav1.ListOptions) (runtime.Object, error) {
+			v, err := newRequest().Do().Get()
+			if err != nil {
+				return nil, err
+			}
+			accessor, err := apimeta.ListAccessor(v)
+			if err == nil && accessor.GetResourceVersion() != "" {
+				mutex.Lock()
+				lastResourceVersion = accessor.GetResourceVersion()
+				mutex.Unlock()
+			}
+			return v, nil
+		},
+		WatchFunc: func(options apismetav1.ListOptions) (watch.Interface, error) {
+			req := newRequest("watch")
+			// Forward the resourceVersion so the stream resumes
+			// where the previous one left off and receives a clean
+			// 410 Gone when the watch window expired, which the
+			// reflector handles with a re-list instead of erroring
+			// until restart.
+			rv := options.ResourceVersion
+			if rv == "" {
+				mutex.Lock()
+				rv = lastResourceVersion
+				mutex.Unlock()
+			}
+			if rv != "" {
+				req = req.Param("resourceVersion", rv)
+			}
+			// Newer apiservers send periodic bookmark events keeping
+			// the resume point fresh, older ones ignore the param.
+			req = req.Param("allowWatchBookmarks", "true")
+
+			w, err := req.Watch()
+			if err != nil {
+				return nil, err
+			}
+			tracker.StreamEstablished()
+			return newResumableWatch(w, storeResourceVersion, tracker.StreamBroken), nil
+		},
+	}
+}
+
+// watchBookmark is the bookmark event type. The vendored watch package
+// predates bookmarks, so the constant is defined here.
+const watchBookmark watch.EventType = "BOOKMARK"
+
+// resumableWatch records the resourceVersion of passing events and swallows
+// bookmark events the vendored reflector doesn't understand.
+type resumableWatch struct {
+	next   watch.Interface
+	result chan watch.Event
+}
+
+func newResumableWatch(next watch.Interface, store func(runtime.Object), onClose func()) *resumableWatch {
+	w := &resumableWatch{
+		next:   next,
+		result: make(chan watch.Event),
+	}
+
+	go func() {
+		defer close(w.result)
+		defer onClose()
+		for event := range next.ResultChan() {
+			if event.Object != nil {
+				store(event.Object)
+			}
+			if event.Type == watchBookmark {
+				continue
+			}
+			w.result <- event
+		}
+	}()
+
+	return w
+}
+
+func (w *resumableWatch) Stop() {
+	w.next.Stop()
+}
+
+func (w *resumableWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
 // newK8sExtClient creates Kubernets extensions API client.
 func newK8sExtClient(config Config) (apiextensionsclient.Interface, error) {
 	restConfig, err := newBaseRestConfig(config)
@@ -234,6 +1042,8 @@ func newK8sCustomRestClient(config Config, group, version string) (rest.Interfac
 			groupVersion,
 			&PostgreSQLConfig{},
 			&PostgreSQLConfigList{},
+			&MySQLConfig{},
+			&MySQLConfigList{},
 		)
 		apismetav1.AddToGroupVersion(scheme, groupVersion)
 	}
@@ -268,5 +1078,14 @@ func newBaseRestConfig(config Config) (*rest.Config, error) {
 		}
 	}
 
+	// Rate limit API calls protecting small API servers from tight
+	// reconcile loops.
+	if config.K8sQPS > 0 {
+		restConfig.QPS = float32(config.K8sQPS)
+	}
+	if config.K8sBurst > 0 {
+		restConfig.Burst = config.K8sBurst
+	}
+
 	return restConfig, nil
 }
@@ -0,0 +1,111 @@
+package solution3
+
+// Hand-written deep copy implementations following the deepcopy-gen
+// conventions. The vendored runtime.Object interface predates
+// DeepCopyObject, but implementing it keeps the types safe to share
+// through client-go caches.
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func deepCopyObjectMeta(in apismetav1.ObjectMeta) apismetav1.ObjectMeta {
+	out := in
+	if in.Labels != nil {
+		out.Labels = make(map[string]string, len(in.Labels))
+		for k, v := range in.Labels {
+			out.Labels[k] = v
+		}
+	}
+	if in.Annotations != nil {
+		out.Annotations = make(map[string]string, len(in.Annotations))
+		for k, v := range in.Annotations {
+			out.Annotations[k] = v
+		}
+	}
+	if in.OwnerReferences != nil {
+		out.OwnerReferences = make([]apismetav1.OwnerReference, len(in.OwnerReferences))
+		copy(out.OwnerReferences, in.OwnerReferences)
+	}
+	if in.Finalizers != nil {
+		out.Finalizers = make([]string, len(in.Finalizers))
+		copy(out.Finalizers, in.Finalizers)
+	}
+	return out
+}
+
+// DeepCopy creates a new PostgreSQLConfig copying the receiver.
+func (in *PostgreSQLConfig) DeepCopy() *PostgreSQLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfig)
+	*out = *in
+	out.ObjectMeta = deepCopyObjectMeta(in.ObjectMeta)
+	return out
+}
+
+// DeepCopyObject creates a new runtime.Object copying the receiver.
+func (in *PostgreSQLConfig) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopy creates a new PostgreSQLConfigList copying the receiver.
+func (in *PostgreSQLConfigList) DeepCopy() *PostgreSQLConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLConfigList)
+	*out = *in
+	if in.Items != nil {
+		out.Items = make([]*PostgreSQLConfig, len(in.Items))
+		for i := range in.Items {
+			out.Items[i] = in.Items[i].DeepCopy()
+		}
+	}
+	return out
+}
+
+// DeepCopyObject creates a new runtime.Object copying the receiver.
+func (in *PostgreSQLConfigList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopy creates a new MySQLConfig copying the receiver.
+func (in *MySQLConfig) DeepCopy() *MySQLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLConfig)
+	*out = *in
+	out.ObjectMeta = deepCopyObjectMeta(in.ObjectMeta)
+	return out
+}
+
+// DeepCopyObject creates a new runtime.Object copying the receiver.
+func (in *MySQLConfig) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopy creates a new MySQLConfigList copying the receiver.
+func (in *MySQLConfigList) DeepCopy() *MySQLConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MySQLConfigList)
+	*out = *in
+	if in.Items != nil {
+		out.Items = make([]*MySQLConfig, len(in.Items))
+		for i := range in.Items {
+			out.Items[i] = in.Items[i].DeepCopy()
+		}
+	}
+	return out
+}
+
+// DeepCopyObject creates a new runtime.Object copying the receiver.
+func (in *MySQLConfigList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
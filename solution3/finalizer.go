@@ -0,0 +1,128 @@
+package solution3
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/giantswarm/operator-workshop/logging"
+	"k8s.io/client-go/rest"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// finalizerName is the finalizer the operator sets on reconciled objects.
+const finalizerName = "containerconf.de/operator-workshop"
+
+// finalizerClient adds and removes the operator's finalizer on custom
+// objects. With the finalizer present the apiserver doesn't remove a deleted
+// object until the operator processed the deletion, so deletes are processed
+// exactly once even across operator restarts.
+//
+// This differs from the plain watch.Deleted approach in solution2. There a
+// delete happening while the operator is down is never observed and the
+// database lives on. With the finalizer the object survives the delete,
+// marked with a deletion timestamp, and is delivered again as an update event
+// once the operator is back.
+//
+// The vendored operatorkit has no finalizer handling, so the finalizer is
+// maintained with plain REST calls.
+type finalizerClient struct {
+	restClient rest.Interface
+	group      string
+	version    string
+	plural     string
+	// dryRun logs finalizer updates instead of performing them.
+	dryRun bool
+}
+
+// ensure adds the finalizer to the object when missing.
+func (c *finalizerClient) ensure(namespace, name string) error {
+	return c.update(namespace, name, func(finalizers []string) ([]string, bool) {
+		for _, f := range finalizers {
+			if f == finalizerName {
+				return nil, false
+			}
+		}
+		return append(finalizers, finalizerName), true
+	})
+}
+
+// remove removes the finalizer from the object, releasing it for actual
+// removal by the apiserver.
+func (c *finalizerClient) remove(namespace, name string) error {
+	return c.update(namespace, name, func(finalizers []string) ([]string, bool) {
+		var kept []string
+		for _, f := range finalizers {
+			if f != finalizerName {
+				kept = append(kept, f)
+			}
+		}
+		return kept, len(kept) != len(finalizers)
+	})
+}
+
+// update applies the mutation to the object's finalizer list. The object is
+// round-tripped as a generic JSON object, so the update preserves the spec.
+// ResourceVersion conflicts are retried with a fresh read. A missing object
+// is fine, there is nothing to update.
+func (c *finalizerClient) update(namespace, name string, mutate func(finalizers []string) ([]string, bool)) error {
+	endpoint := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s/%s", c.group, c.version, namespace, c.plural, name)
+
+	for {
+		body, err := c.restClient.Get().AbsPath(endpoint).DoRaw()
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("requesting object endpoint=%s: %s", endpoint, err)
+		}
+
+		var obj map[string]interface{}
+		err = json.Unmarshal(body, &obj)
+		if err != nil {
+			return fmt.Errorf("decoding object: %s", err)
+		}
+
+		metadata, ok := obj["metadata"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("decoding object: no metadata")
+		}
+
+		var finalizers []string
+		if raw, ok := metadata["finalizers"].([]interface{}); ok {
+			for _, f := range raw {
+				if s, ok := f.(string); ok {
+					finalizers = append(finalizers, s)
+				}
+			}
+		}
+
+		finalizers, changed := mutate(finalizers)
+		if !changed {
+			return nil
+		}
+		if c.dryRun {
+			logging.Infof("dry-run: would update finalizers namespace=%s name=%s", namespace, name)
+			return nil
+		}
+		metadata["finalizers"] = finalizers
+
+		body, err = json.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("marshalling object: %s", err)
+		}
+
+		_, err = c.restClient.Put().AbsPath(endpoint).Body(body).DoRaw()
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("updating object endpoint=%s: %s", endpoint, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package solution3
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	informerErrorTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "solution3",
+			Name:      "informer_error_total",
+			Help:      "Number of informer errors by resource.",
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(informerErrorTotal)
+}
@@ -0,0 +1,448 @@
+package solution3
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/eventrecorder"
+	"github.com/giantswarm/operator-workshop/reconcilemetrics"
+	"github.com/giantswarm/operator-workshop/tracing"
+	"github.com/giantswarm/operatorkit/framework"
+)
+
+// databaseResource reconciles PostgreSQLConfig objects implementing the
+// operatorkit framework CRUD interface. The current state is the database as
+// it exists on the target server, the desired state is the database described
+// by the object spec. The apply functions delegate to the idempotent
+// customobject ensure logic, which additionally covers users, grants and
+// migrations not being part of the listed database state.
+type databaseResource struct {
+	logger     micrologger.Logger
+	resource   *customobject.Resource
+	recorder   *eventrecorder.Recorder
+	finalizers *finalizerClient
+
+	selectorMatches func(objLabels map[string]string) bool
+	namespacePrefix bool
+}
+
+func (r *databaseResource) Name() string {
+	return "database"
+}
+
+func (r *databaseResource) Underlying() framework.Resource {
+	return r
+}
+
+// desiredObject translates the watched object into the custom object to be
+// reconciled. It returns nil when the object is filtered out and must not be
+// reconciled at all.
+func (r *databaseResource) desiredObject(obj interface{}) *customobject.PostgreSQLConfig {
+	postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
+	if !ok {
+		r.logger.Log("warning", fmt.Sprintf("wrong type %T, want %T", obj, postgreSQLConfig))
+		return nil
+	}
+	if !r.selectorMatches(postgreSQLConfig.Labels) {
+		return nil
+	}
+	if customobject.IsPaused(postgreSQLConfig.Annotations) {
+		r.logger.Log("debug", "reconciliation paused", "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name)
+		return nil
+	}
+	err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("invalid object: %s", err), "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name)
+		r.recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+		return nil
+	}
+
+	o := &postgreSQLConfig.PostgreSQLConfig
+	if r.namespacePrefix {
+		o = customobject.NamespacePrefixed(o, postgreSQLConfig.Namespace)
+	}
+
+	return o
+}
+
+func (r *databaseResource) GetCurrentState(ctx context.Context, obj interface{}) (interface{}, error) {
+	o := r.desiredObject(obj)
+	if o == nil {
+		return nil, nil
+	}
+
+	db, err := r.resource.CurrentDatabase(o)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return nil, nil
+	}
+
+	return db, nil
+}
+
+func (r *databaseResource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	o := r.desiredObject(obj)
+	if o == nil {
+		return nil, nil
+	}
+
+	return o, nil
+}
+
+func (r *databaseResource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*framework.Patch, error) {
+	patch := framework.NewPatch()
+
+	// A nil desired state means the object is filtered out, there is
+	// nothing to apply.
+	if desiredState == nil {
+		return patch, nil
+	}
+
+	// An update event carrying a deletion timestamp is an object going
+	// away. The finalizer keeps the object on the apiserver until the
+	// deletion is processed, so the delete change is applied through the
+	// update path and the finalizer is removed afterwards.
+	if postgreSQLConfig, ok := obj.(*PostgreSQLConfig); ok && postgreSQLConfig.DeletionTimestamp != nil {
+		patch.SetDeleteChange(desiredState)
+		return patch, nil
+	}
+
+	if currentState == nil {
+		patch.SetCreateChange(desiredState)
+		return patch, nil
+	}
+
+	// The update change is set whenever the database exists. The ensure
+	// logic is idempotent and additionally reconciles users, grants and
+	// migrations not being part of the listed database state.
+	patch.SetUpdateChange(desiredState)
+
+	return patch, nil
+}
+
+func (r *databaseResource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*framework.Patch, error) {
+	patch := framework.NewPatch()
+
+	if desiredState == nil {
+		return patch, nil
+	}
+
+	// The delete change is set even without a current database, the ensure
+	// logic additionally deletes orphan owner roles.
+	patch.SetDeleteChange(desiredState)
+
+	return patch, nil
+}
+
+func (r *databaseResource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) (err error) {
+	ctx, reconcileSpan := tracing.Start(ctx, "reconcile")
+	defer func() { reconcileSpan.End(err) }()
+
+	// A panicking reconciliation must not crash the whole operator, other
+	// objects can still be processed.
+	defer recoverToError(&err)
+
+	postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong type %T, want %T", obj, postgreSQLConfig)
+	}
+	o, ok := createChange.(*customobject.PostgreSQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong change type %T, want %T", createChange, o)
+	}
+	reconcileSpan.SetAttribute("solution", "solution3")
+	reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+	reconcileSpan.SetAttribute("event", "update")
+	reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+	reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+	// The finalizer makes deletes observable across operator restarts.
+	_, finalizerSpan := tracing.Start(ctx, "finalizer")
+	err = r.finalizers.ensure(postgreSQLConfig.Namespace, postgreSQLConfig.Name)
+	finalizerSpan.End(err)
+	if err != nil {
+		return fmt.Errorf("ensuring finalizer: %s", err)
+	}
+
+	start := time.Now()
+	_, ensureSpan := tracing.Start(ctx, "ensure")
+	status, err := r.resource.EnsureCreated(o)
+	ensureSpan.End(err)
+	reconcilemetrics.Observe("solution3", "postgresqlconfigs", "update", status, start, err)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("processing update: %s", err), "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name, "event", "update", "duration", time.Since(start))
+		r.recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+		return err
+	}
+
+	r.logger.Log("info", "reconciled", "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name, "event", "update", "status", status, "duration", time.Since(start))
+	r.recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+	return nil
+}
+
+func (r *databaseResource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	// Create and update apply the same idempotent ensure logic.
+	return r.ApplyCreateChange(ctx, obj, updateChange)
+}
+
+func (r *databaseResource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) (err error) {
+	ctx, reconcileSpan := tracing.Start(ctx, "reconcile")
+	defer func() { reconcileSpan.End(err) }()
+
+	// A panicking reconciliation must not crash the whole operator, other
+	// objects can still be processed.
+	defer recoverToError(&err)
+
+	postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong type %T, want %T", obj, postgreSQLConfig)
+	}
+	o, ok := deleteChange.(*customobject.PostgreSQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong change type %T, want %T", deleteChange, o)
+	}
+	reconcileSpan.SetAttribute("solution", "solution3")
+	reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+	reconcileSpan.SetAttribute("event", "delete")
+	reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+	reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+	start := time.Now()
+	_, ensureSpan := tracing.Start(ctx, "ensure")
+	status, err := r.resource.EnsureDeleted(o)
+	ensureSpan.End(err)
+	reconcilemetrics.Observe("solution3", "postgresqlconfigs", "delete", status, start, err)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("processing delete: %s", err), "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name, "event", "delete", "duration", time.Since(start))
+		r.recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+		return err
+	}
+
+	// The deletion is processed, release the object for actual removal.
+	_, finalizerSpan := tracing.Start(ctx, "finalizer")
+	err = r.finalizers.remove(postgreSQLConfig.Namespace, postgreSQLConfig.Name)
+	finalizerSpan.End(err)
+	if err != nil {
+		return fmt.Errorf("removing finalizer: %s", err)
+	}
+
+	r.logger.Log("info", "reconciled", "namespace", postgreSQLConfig.Namespace, "object", postgreSQLConfig.Name, "event", "delete", "status", status, "duration", time.Since(start))
+	r.recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+	return nil
+}
+
+// mysqlDatabaseResource mirrors databaseResource for MySQLConfig objects.
+type mysqlDatabaseResource struct {
+	logger     micrologger.Logger
+	resource   *customobject.MySQLResource
+	recorder   *eventrecorder.Recorder
+	finalizers *finalizerClient
+
+	selectorMatches func(objLabels map[string]string) bool
+}
+
+func (r *mysqlDatabaseResource) Name() string {
+	return "mysqldatabase"
+}
+
+func (r *mysqlDatabaseResource) Underlying() framework.Resource {
+	return r
+}
+
+// desiredObject translates the watched object into the custom object to be
+// reconciled. It returns nil when the object is filtered out and must not be
+// reconciled at all.
+func (r *mysqlDatabaseResource) desiredObject(obj interface{}) *customobject.MySQLConfig {
+	mySQLConfig, ok := obj.(*MySQLConfig)
+	if !ok {
+		r.logger.Log("warning", fmt.Sprintf("wrong type %T, want %T", obj, mySQLConfig))
+		return nil
+	}
+	if !r.selectorMatches(mySQLConfig.Labels) {
+		return nil
+	}
+	if customobject.IsPaused(mySQLConfig.Annotations) {
+		r.logger.Log("debug", "reconciliation paused", "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name)
+		return nil
+	}
+	err := customobject.ValidateMySQL(mySQLConfig.MySQLConfig)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("invalid object: %s", err), "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name)
+		r.recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+		return nil
+	}
+
+	return &mySQLConfig.MySQLConfig
+}
+
+func (r *mysqlDatabaseResource) GetCurrentState(ctx context.Context, obj interface{}) (interface{}, error) {
+	o := r.desiredObject(obj)
+	if o == nil {
+		return nil, nil
+	}
+
+	db, err := r.resource.CurrentDatabase(o)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return nil, nil
+	}
+
+	return db, nil
+}
+
+func (r *mysqlDatabaseResource) GetDesiredState(ctx context.Context, obj interface{}) (interface{}, error) {
+	o := r.desiredObject(obj)
+	if o == nil {
+		return nil, nil
+	}
+
+	return o, nil
+}
+
+func (r *mysqlDatabaseResource) NewUpdatePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*framework.Patch, error) {
+	patch := framework.NewPatch()
+
+	if desiredState == nil {
+		return patch, nil
+	}
+
+	// Deletion-timestamped updates are processed as deletes, mirroring the
+	// PostgreSQL resource.
+	if mySQLConfig, ok := obj.(*MySQLConfig); ok && mySQLConfig.DeletionTimestamp != nil {
+		patch.SetDeleteChange(desiredState)
+		return patch, nil
+	}
+
+	if currentState == nil {
+		patch.SetCreateChange(desiredState)
+		return patch, nil
+	}
+
+	patch.SetUpdateChange(desiredState)
+
+	return patch, nil
+}
+
+func (r *mysqlDatabaseResource) NewDeletePatch(ctx context.Context, obj, currentState, desiredState interface{}) (*framework.Patch, error) {
+	patch := framework.NewPatch()
+
+	if desiredState == nil {
+		return patch, nil
+	}
+
+	patch.SetDeleteChange(desiredState)
+
+	return patch, nil
+}
+
+func (r *mysqlDatabaseResource) ApplyCreateChange(ctx context.Context, obj, createChange interface{}) (err error) {
+	ctx, reconcileSpan := tracing.Start(ctx, "reconcile")
+	defer func() { reconcileSpan.End(err) }()
+
+	// A panicking reconciliation must not crash the whole operator, other
+	// objects can still be processed.
+	defer recoverToError(&err)
+
+	mySQLConfig, ok := obj.(*MySQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong type %T, want %T", obj, mySQLConfig)
+	}
+	o, ok := createChange.(*customobject.MySQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong change type %T, want %T", createChange, o)
+	}
+	reconcileSpan.SetAttribute("solution", "solution3")
+	reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+	reconcileSpan.SetAttribute("event", "update")
+	reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+	reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+	// The finalizer makes deletes observable across operator restarts.
+	_, finalizerSpan := tracing.Start(ctx, "finalizer")
+	err = r.finalizers.ensure(mySQLConfig.Namespace, mySQLConfig.Name)
+	finalizerSpan.End(err)
+	if err != nil {
+		return fmt.Errorf("ensuring finalizer: %s", err)
+	}
+
+	start := time.Now()
+	_, ensureSpan := tracing.Start(ctx, "ensure")
+	status, err := r.resource.EnsureCreated(o)
+	ensureSpan.End(err)
+	reconcilemetrics.Observe("solution3", "mysqlconfigs", "update", status, start, err)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("processing update: %s", err), "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name, "event", "update", "duration", time.Since(start))
+		r.recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+		return err
+	}
+
+	r.logger.Log("info", "reconciled", "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name, "event", "update", "status", status, "duration", time.Since(start))
+	r.recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+	return nil
+}
+
+func (r *mysqlDatabaseResource) ApplyUpdateChange(ctx context.Context, obj, updateChange interface{}) error {
+	// Create and update apply the same idempotent ensure logic.
+	return r.ApplyCreateChange(ctx, obj, updateChange)
+}
+
+func (r *mysqlDatabaseResource) ApplyDeleteChange(ctx context.Context, obj, deleteChange interface{}) (err error) {
+	ctx, reconcileSpan := tracing.Start(ctx, "reconcile")
+	defer func() { reconcileSpan.End(err) }()
+
+	// A panicking reconciliation must not crash the whole operator, other
+	// objects can still be processed.
+	defer recoverToError(&err)
+
+	mySQLConfig, ok := obj.(*MySQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong type %T, want %T", obj, mySQLConfig)
+	}
+	o, ok := deleteChange.(*customobject.MySQLConfig)
+	if !ok {
+		return fmt.Errorf("wrong change type %T, want %T", deleteChange, o)
+	}
+	reconcileSpan.SetAttribute("solution", "solution3")
+	reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+	reconcileSpan.SetAttribute("event", "delete")
+	reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+	reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+	start := time.Now()
+	_, ensureSpan := tracing.Start(ctx, "ensure")
+	status, err := r.resource.EnsureDeleted(o)
+	ensureSpan.End(err)
+	reconcilemetrics.Observe("solution3", "mysqlconfigs", "delete", status, start, err)
+	if err != nil {
+		r.logger.Log("error", fmt.Sprintf("processing delete: %s", err), "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name, "event", "delete", "duration", time.Since(start))
+		r.recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+		return err
+	}
+
+	// The deletion is processed, release the object for actual removal.
+	_, finalizerSpan := tracing.Start(ctx, "finalizer")
+	err = r.finalizers.remove(mySQLConfig.Namespace, mySQLConfig.Name)
+	finalizerSpan.End(err)
+	if err != nil {
+		return fmt.Errorf("removing finalizer: %s", err)
+	}
+
+	r.logger.Log("info", "reconciled", "namespace", mySQLConfig.Namespace, "object", mySQLConfig.Name, "event", "delete", "status", status, "duration", time.Since(start))
+	r.recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+	return nil
+}
+
+// recoverToError converts a panic in a reconciliation into a returned error.
+func recoverToError(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+	}
+}
@@ -3,17 +3,30 @@ package solution3
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/http"
+	"time"
 
 	"github.com/cenk/backoff"
 	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operator-workshop/crdutil"
 	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/eventrecorder"
+	"github.com/giantswarm/operator-workshop/leaderelect"
+	"github.com/giantswarm/operator-workshop/mysqlops"
+	"github.com/giantswarm/operator-workshop/pkg/healthz"
 	"github.com/giantswarm/operator-workshop/postgresqlops"
-	"github.com/giantswarm/operatorkit/client/k8sextclient"
+	"github.com/giantswarm/operator-workshop/serverref"
 	operatorkitcrd "github.com/giantswarm/operatorkit/crd"
 	"github.com/giantswarm/operatorkit/crdclient"
+	operatorkitframework "github.com/giantswarm/operatorkit/framework"
+	"github.com/giantswarm/operatorkit/framework/resource/metricsresource"
+	"github.com/giantswarm/operatorkit/framework/resource/retryresource"
 	operatorkitinformer "github.com/giantswarm/operatorkit/informer"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
 
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +37,65 @@ type Config struct {
 	DBPort     int
 	DBUser     string
 	DBPassword string
+	// DBPasswordFile is a file holding the password, e.g. a mounted
+	// Secret volume. It takes precedence over DBPassword.
+	DBPasswordFile string
+
+	// DryRun logs mutating actions, SQL statements and Kubernetes
+	// writes, instead of performing them.
+	DryRun bool
+
+	// MySQLHost enables the MySQLConfig reconciler when set. The workshop
+	// can run against Postgres only.
+	MySQLHost     string
+	MySQLPort     int
+	MySQLUser     string
+	MySQLPassword string
+
+	// NamespacePrefix derives actual database names as
+	// <namespace>_<spec.database> preventing cross-namespace collisions.
+	NamespacePrefix bool
+
+	// Namespace restricts the operator to objects in one namespace. Empty
+	// watches all namespaces.
+	Namespace string
+	// LabelSelector restricts the operator to objects matching the
+	// selector. The vendored watcher factory doesn't support query
+	// parameters, the filtering happens client side in the handlers.
+	LabelSelector string
+
+	// ResyncPeriod is how often all known objects are replayed through
+	// the update path even without API events, correcting manual changes
+	// on the database server. Zero uses the informer default.
+	ResyncPeriod time.Duration
+	// RateWait is the time the informer waits between releasing single
+	// update events, spreading reconciliation load over the resync period.
+	// Zero releases all events at once.
+	RateWait time.Duration
+
+	// LeaderElect enables leader election, so multiple operator replicas
+	// can run for availability while only the leader reconciles.
+	LeaderElect bool
+	// LeaderElectIdentity is this replica's identity, e.g. the pod name.
+	// It must be unique per replica.
+	LeaderElectIdentity string
+	// LeaderElectLeaseDuration is how long a leadership is valid without
+	// renewal.
+	LeaderElectLeaseDuration time.Duration
+
+	// MetricsAddr is the address the Prometheus metrics endpoint is
+	// served on. Empty disables the endpoint.
+	MetricsAddr string
+	// HealthAddr is the address the liveness and readiness probe
+	// endpoints are served on. Empty disables the endpoints.
+	HealthAddr string
+
+	// K8sQPS limits queries per second against the Kubernetes API. Zero
+	// uses the client default.
+	K8sQPS float64
+	// K8sBurst is the maximum burst of queries against the Kubernetes
+	// API. Zero uses the client default.
+	K8sBurst int
 
 	K8sInCluster bool
 	K8sServer    string
@@ -50,6 +122,24 @@ type PostgreSQLConfigList struct {
 	Items []*PostgreSQLConfig `json:"items"`
 }
 
+// MySQLConfig embeds customobject.MySQLConfig adding fields required by
+// runtime.Object interface.
+type MySQLConfig struct {
+	apismetav1.TypeMeta   `json:",inline"`
+	apismetav1.ObjectMeta `json:"metadata,omitempty"`
+
+	customobject.MySQLConfig `json:",inline"`
+}
+
+// MySQLConfigList embeds customobject.MySQLConfigList adding fields required
+// by runtime.Object interface.
+type MySQLConfigList struct {
+	apismetav1.TypeMeta `json:",inline"`
+	apismetav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []*MySQLConfig `json:"items"`
+}
+
 func Run(ctx context.Context, config Config) error {
 	var err error
 
@@ -62,21 +152,80 @@ func Run(ctx context.Context, config Config) error {
 		}
 	}
 
+	// The client is built from a rest.Config directly instead of the
+	// operatorkit k8sextclient, which hardcodes its rate limits.
 	var k8sClient apiextensionsclient.Interface
 	{
-		c := k8sextclient.DefaultConfig()
-		c.Logger = logger
-		c.InCluster = config.K8sInCluster
-		c.Address = config.K8sServer
-		c.TLS.CrtFile = config.K8sCrtFile
-		c.TLS.CAFile = config.K8sCAFile
-		c.TLS.KeyFile = config.K8sKeyFile
-		k8sClient, err = k8sextclient.New(c)
+		var restConfig *rest.Config
+		if config.K8sInCluster {
+			restConfig, err = rest.InClusterConfig()
+			if err != nil {
+				return fmt.Errorf("creating incluster config: %s", err)
+			}
+		} else {
+			restConfig = &rest.Config{
+				Host: config.K8sServer,
+				TLSClientConfig: rest.TLSClientConfig{
+					CertFile: config.K8sCrtFile,
+					KeyFile:  config.K8sKeyFile,
+					CAFile:   config.K8sCAFile,
+				},
+			}
+		}
+
+		if config.K8sQPS > 0 {
+			restConfig.QPS = float32(config.K8sQPS)
+		}
+		if config.K8sBurst > 0 {
+			restConfig.Burst = config.K8sBurst
+		}
+
+		k8sClient, err = apiextensionsclient.NewForConfig(restConfig)
 		if err != nil {
 			return fmt.Errorf("creating k8s api extensions client: %s", err)
 		}
 	}
 
+	// Wait to become the leader before doing any work, so multiple
+	// replicas can run for availability while only one reconciles.
+	if config.LeaderElect {
+		var elector *leaderelect.Elector
+		{
+			c := leaderelect.Config{
+				RESTClient:    k8sClient.Apiextensions().RESTClient(),
+				Namespace:     config.Namespace,
+				Name:          "operator-workshop-solution3",
+				Identity:      config.LeaderElectIdentity,
+				LeaseDuration: config.LeaderElectLeaseDuration,
+			}
+			elector, err = leaderelect.New(c)
+			if err != nil {
+				return fmt.Errorf("creating elector: %s", err)
+			}
+		}
+
+		logger.Log("info", "waiting to become leader", "identity", config.LeaderElectIdentity)
+		lostCh, err := elector.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("electing leader: %s", err)
+		}
+		logger.Log("info", "became leader", "identity", config.LeaderElectIdentity)
+
+		// Stop all work when the leadership is lost, another replica
+		// takes over.
+		electionCtx, electionCancel := context.WithCancel(ctx)
+		defer electionCancel()
+		go func() {
+			select {
+			case <-lostCh:
+				logger.Log("warning", "leadership lost, shutting down")
+				electionCancel()
+			case <-electionCtx.Done():
+			}
+		}()
+		ctx = electionCtx
+	}
+
 	var crd *operatorkitcrd.CRD
 	{
 		c := operatorkitcrd.DefaultConfig()
@@ -93,6 +242,22 @@ func Run(ctx context.Context, config Config) error {
 		}
 	}
 
+	var mysqlCRD *operatorkitcrd.CRD
+	{
+		c := operatorkitcrd.DefaultConfig()
+		c.Group = "containerconf.de"
+		c.Kind = "MySQLConfig"
+		c.Version = "v1"
+		c.Name = "mysqlconfigs.containerconf.de"
+		c.Plural = "mysqlconfigs"
+		c.Singular = "mysqlconfig"
+		c.Scope = "Namespaced"
+		mysqlCRD, err = operatorkitcrd.New(c)
+		if err != nil {
+			return fmt.Errorf("creating operatorkit/crd: %s", err)
+		}
+	}
+
 	var crdClient *crdclient.CRDClient
 	{
 		c := crdclient.DefaultConfig()
@@ -104,15 +269,31 @@ func Run(ctx context.Context, config Config) error {
 		}
 	}
 
-	// Create Custom Resource Definition.
-	{
-		log.Printf("creating custom resource")
+	// Create Custom Resource Definitions.
+	for _, c := range []*operatorkitcrd.CRD{crd, mysqlCRD} {
+		if config.DryRun {
+			logger.Log("debug", "dry-run: would create custom resource", "name", c.Name())
+			continue
+		}
+		logger.Log("debug", "creating custom resource", "name", c.Name())
 		backOff := backoff.WithMaxTries(backoff.NewExponentialBackOff(), 10)
-		err := crdClient.Ensure(ctx, crd, backOff)
+		err := crdClient.Ensure(ctx, c, backOff)
 		if err != nil {
 			return fmt.Errorf("creating custom resource: %s", err)
 		}
-		log.Printf("creating custom resource: created")
+		logger.Log("debug", "created custom resource", "name", c.Name())
+	}
+
+	// Install kubectl get columns. Best effort, apiservers predating
+	// additionalPrinterColumns drop them. Skipped in dry-run mode.
+	for _, c := range []*operatorkitcrd.CRD{crd, mysqlCRD} {
+		if config.DryRun {
+			continue
+		}
+		err := crdutil.EnsurePrinterColumns(k8sClient.Apiextensions().RESTClient(), c.Name(), crdutil.ConfigColumns)
+		if err != nil {
+			logger.Log("warning", fmt.Sprintf("installing printer columns: %s", err), "name", c.Name())
+		}
 	}
 
 	// Create an informer.
@@ -123,19 +304,64 @@ func Run(ctx context.Context, config Config) error {
 			NewObjectListFunc: func() runtime.Object { return new(PostgreSQLConfigList) },
 		}
 
+		// The watch endpoint honors the configured namespace scope.
+		watchEndpoint := crd.WatchEndpoint()
+		if config.Namespace != "" {
+			watchEndpoint = fmt.Sprintf("/apis/%s/%s/watch/namespaces/%s/%s", crd.Group(), crd.Version(), config.Namespace, crd.Plural())
+		}
+
 		watcherFactory := operatorkitinformer.NewWatcherFactory(
 			k8sClient.Apiextensions().RESTClient(),
-			crd.WatchEndpoint(),
+			watchEndpoint,
 			zeroObjectFactory,
 		)
 
 		c := operatorkitinformer.DefaultConfig()
 		c.BackOff = backoff.WithMaxTries(backoff.NewExponentialBackOff(), 10)
 		c.WatcherFactory = watcherFactory
+		c.RateWait = config.RateWait
+		if config.ResyncPeriod != 0 {
+			c.ResyncPeriod = config.ResyncPeriod
+		}
 
 		informer, err = operatorkitinformer.New(c)
 		if err != nil {
-			log.Printf("creating informer: %s", err)
+			return fmt.Errorf("creating informer: %s", err)
+		}
+	}
+
+	// Create an informer for MySQLConfig objects when a MySQL server is
+	// configured.
+	var mysqlInformer *operatorkitinformer.Informer
+	if config.MySQLHost != "" {
+		zeroObjectFactory := operatorkitinformer.ZeroObjectFactoryFuncs{
+			NewObjectFunc:     func() runtime.Object { return new(MySQLConfig) },
+			NewObjectListFunc: func() runtime.Object { return new(MySQLConfigList) },
+		}
+
+		// The watch endpoint honors the configured namespace scope.
+		watchEndpoint := mysqlCRD.WatchEndpoint()
+		if config.Namespace != "" {
+			watchEndpoint = fmt.Sprintf("/apis/%s/%s/watch/namespaces/%s/%s", mysqlCRD.Group(), mysqlCRD.Version(), config.Namespace, mysqlCRD.Plural())
+		}
+
+		watcherFactory := operatorkitinformer.NewWatcherFactory(
+			k8sClient.Apiextensions().RESTClient(),
+			watchEndpoint,
+			zeroObjectFactory,
+		)
+
+		c := operatorkitinformer.DefaultConfig()
+		c.BackOff = backoff.WithMaxTries(backoff.NewExponentialBackOff(), 10)
+		c.WatcherFactory = watcherFactory
+		c.RateWait = config.RateWait
+		if config.ResyncPeriod != 0 {
+			c.ResyncPeriod = config.ResyncPeriod
+		}
+
+		mysqlInformer, err = operatorkitinformer.New(c)
+		if err != nil {
+			return fmt.Errorf("creating mysql informer: %s", err)
 		}
 	}
 
@@ -143,10 +369,12 @@ func Run(ctx context.Context, config Config) error {
 	var ops *postgresqlops.PostgreSQLOps
 	{
 		config := postgresqlops.Config{
-			Host:     config.DBHost,
-			Port:     config.DBPort,
-			User:     config.DBUser,
-			Password: config.DBPassword,
+			Host:         config.DBHost,
+			Port:         config.DBPort,
+			User:         config.DBUser,
+			Password:     config.DBPassword,
+			PasswordFile: config.DBPasswordFile,
+			DryRun:       config.DryRun,
 		}
 
 		ops, err = postgresqlops.New(config)
@@ -155,67 +383,318 @@ func Run(ctx context.Context, config Config) error {
 		}
 
 		defer ops.Close()
+
+		err = ops.CheckCompatibility()
+		if err != nil {
+			return fmt.Errorf("checking server compatibility: %s", err)
+		}
 	}
 
-	// Create a resource instance providing reconciliation methods.
+	// Create a resource instance providing reconciliation methods. The
+	// resolver lets objects target other Postgres servers via
+	// Spec.ServerRef.
 	var resource *customobject.Resource
 	{
-		resource = customobject.NewResource(ops)
+		resolver := serverref.NewResolver(k8sClient.Apiextensions().RESTClient())
+		resource = customobject.NewResourceWithResolver(ops, resolver)
+		resource.SetSecretReader(serverref.NewSecretReader(k8sClient.Apiextensions().RESTClient()))
+		resource.SetConfigMapReader(serverref.NewConfigMapReader(k8sClient.Apiextensions().RESTClient()))
+	}
+
+	// Create an event recorder making reconciliation outcomes visible via
+	// kubectl describe.
+	var recorder *eventrecorder.Recorder
+	{
+		c := eventrecorder.Config{
+			RESTClient: k8sClient.Apiextensions().RESTClient(),
+			Component:  "operator-workshop-solution3",
+			DryRun:     config.DryRun,
+		}
+		recorder = eventrecorder.New(c)
+	}
+
+	// Compile the label selector filter applied in the handlers.
+	var selectorMatches func(objLabels map[string]string) bool
+	{
+		selector := labels.Everything()
+		if config.LabelSelector != "" {
+			selector, err = labels.Parse(config.LabelSelector)
+			if err != nil {
+				return fmt.Errorf("parsing label selector=%#q: %s", config.LabelSelector, err)
+			}
+		}
+		selectorMatches = func(objLabels map[string]string) bool {
+			return selector.Matches(labels.Set(objLabels))
+		}
 	}
 
-	// Create reconciliation events handler functions.
+	// Create MySQLOps and a MySQL resource instance when a MySQL server is
+	// configured.
+	var mysqlResource *customobject.MySQLResource
+	if config.MySQLHost != "" {
+		var mysqlOps *mysqlops.MySQLOps
+		{
+			c := mysqlops.Config{
+				Host:     config.MySQLHost,
+				Port:     config.MySQLPort,
+				User:     config.MySQLUser,
+				Password: config.MySQLPassword,
+				DryRun:   config.DryRun,
+			}
 
-	onUpdateFunc := func(obj interface{}) {
-		postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
-		if !ok {
-			log.Printf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+			mysqlOps, err = mysqlops.New(c)
+			if err != nil {
+				return fmt.Errorf("creating MySQLOps: %s", err)
+			}
+
+			defer mysqlOps.Close()
 		}
-		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+
+		mysqlResource = customobject.NewMySQLResource(mysqlOps)
+	}
+
+	// Create the CRUD resources executed by the operator framework for
+	// every reconciled object. The database resource translates watched
+	// objects into current and desired state and applies the resulting
+	// patches against the database server.
+	resources := []operatorkitframework.Resource{
+		&databaseResource{
+			logger:   logger,
+			resource: resource,
+			recorder: recorder,
+			finalizers: &finalizerClient{
+				restClient: k8sClient.Apiextensions().RESTClient(),
+				group:      crd.Group(),
+				version:    crd.Version(),
+				plural:     crd.Plural(),
+				dryRun:     config.DryRun,
+			},
+
+			selectorMatches: selectorMatches,
+			namespacePrefix: config.NamespacePrefix,
+		},
+	}
+
+	// Wrap the resources with the retry resource, so transient database
+	// errors are retried in place with backoff instead of aborting the
+	// reconciliation.
+	{
+		c := retryresource.DefaultWrapConfig()
+		c.BackOffFactory = func() backoff.BackOff { return backoff.WithMaxTries(backoff.NewExponentialBackOff(), 3) }
+		c.Logger = logger
+		resources, err = retryresource.Wrap(resources, c)
 		if err != nil {
-			log.Printf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			return fmt.Errorf("wrapping resources with retries: %s", err)
 		}
+	}
 
-		status, err := resource.EnsureCreated(&postgreSQLConfig.PostgreSQLConfig)
+	// Wrap the resources with the metrics resource, so operation durations
+	// and error counts are exported per resource and operation. The metrics
+	// resource wraps the retry resource, exported durations include the
+	// retries.
+	{
+		c := metricsresource.DefaultWrapConfig()
+		c.Name = "operator-workshop-solution3"
+		resources, err = metricsresource.Wrap(resources, c)
 		if err != nil {
-			log.Printf("reconciling: error: processing update obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
-		} else {
-			log.Printf("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			return fmt.Errorf("wrapping resources with metrics: %s", err)
+		}
+	}
+
+	var operatorFramework *operatorkitframework.Framework
+	{
+		c := operatorkitframework.DefaultConfig()
+		c.BackOffFactory = func() backoff.BackOff { return backoff.WithMaxTries(backoff.NewExponentialBackOff(), 10) }
+		c.Informer = informer
+		c.Logger = logger
+		c.ResourceRouter = func(ctx context.Context, obj interface{}) ([]operatorkitframework.Resource, error) {
+			return resources, nil
+		}
+
+		operatorFramework, err = operatorkitframework.New(c)
+		if err != nil {
+			return fmt.Errorf("creating operatorkit/framework: %s", err)
 		}
 	}
 
-	onDeleteFunc := func(obj interface{}) {
-		postgreSQLConfig, ok := obj.(*PostgreSQLConfig)
-		if !ok {
-			log.Printf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+	// Create a second framework processing MySQLConfig objects when a MySQL
+	// server is configured.
+	var mysqlFramework *operatorkitframework.Framework
+	if mysqlInformer != nil {
+		mysqlResources := []operatorkitframework.Resource{
+			&mysqlDatabaseResource{
+				logger:   logger,
+				resource: mysqlResource,
+				recorder: recorder,
+				finalizers: &finalizerClient{
+					restClient: k8sClient.Apiextensions().RESTClient(),
+					group:      mysqlCRD.Group(),
+					version:    mysqlCRD.Version(),
+					plural:     mysqlCRD.Plural(),
+					dryRun:     config.DryRun,
+				},
+
+				selectorMatches: selectorMatches,
+			},
+		}
+
+		// Wrap the resources with the retry and metrics resources,
+		// mirroring the PostgreSQL framework.
+		{
+			c := retryresource.DefaultWrapConfig()
+			c.BackOffFactory = func() backoff.BackOff { return backoff.WithMaxTries(backoff.NewExponentialBackOff(), 3) }
+			c.Logger = logger
+			mysqlResources, err = retryresource.Wrap(mysqlResources, c)
+			if err != nil {
+				return fmt.Errorf("wrapping mysql resources with retries: %s", err)
+			}
 		}
-		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+		mysqlResources, err = metricsresource.Wrap(mysqlResources, metricsresource.WrapConfig{Name: "operator-workshop-solution3"})
 		if err != nil {
-			log.Printf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			return fmt.Errorf("wrapping mysql resources with metrics: %s", err)
 		}
 
-		status, err := resource.EnsureDeleted(&postgreSQLConfig.PostgreSQLConfig)
+		c := operatorkitframework.DefaultConfig()
+		c.BackOffFactory = func() backoff.BackOff { return backoff.WithMaxTries(backoff.NewExponentialBackOff(), 10) }
+		c.Informer = mysqlInformer
+		c.Logger = logger
+		c.ResourceRouter = func(ctx context.Context, obj interface{}) ([]operatorkitframework.Resource, error) {
+			return mysqlResources, nil
+		}
+
+		mysqlFramework, err = operatorkitframework.New(c)
 		if err != nil {
-			log.Printf("reconciling: error: processing delete obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
-		} else {
-			log.Printf("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			return fmt.Errorf("creating mysql operatorkit/framework: %s", err)
 		}
 	}
 
-	// Start reconciliation loop.
+	// Serve liveness and readiness probes when an address is configured.
+	// Readiness covers the Kubernetes API and the database server. The
+	// operatorkit informer doesn't expose stream health, so there is no
+	// watch check here.
+	if config.HealthAddr != "" {
+		s := healthz.New(healthz.Config{
+			Address: config.HealthAddr,
+			Checks: map[string]healthz.Check{
+				"kubernetes": func(ctx context.Context) error {
+					_, err := k8sClient.Apiextensions().RESTClient().Get().AbsPath("/version").DoRaw()
+					return err
+				},
+				"postgresql": func(ctx context.Context) error {
+					return ops.Ping(ctx)
+				},
+			},
+		})
+		go func() {
+			err := s.Run()
+			if err != nil {
+				logger.Log("error", fmt.Sprintf("serving health endpoints: %s", err), "addr", config.HealthAddr)
+			}
+		}()
+	}
+
+	// Serve the Prometheus registry, including the metrics resource
+	// metrics, when an address is configured.
+	if config.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			logger.Log("info", "serving metrics", "addr", config.MetricsAddr)
+			err := http.ListenAndServe(config.MetricsAddr, mux)
+			if err != nil {
+				logger.Log("error", fmt.Sprintf("serving metrics: %s", err), "addr", config.MetricsAddr)
+			}
+		}()
+	}
 
 	// In Giant Swarm we believe that you should treat Added and Updated as
 	// the same thing. Otherwise you most likely don't write a correct
 	// reconciliation.
-	deleteChan, updateChan, errChan := informer.Watch(ctx)
+	//
+	// The framework's Boot starts its own watch detached from ctx, so the
+	// informer channels are created in processEvents and fed through the
+	// framework's event processing instead, keeping cancellation via leader
+	// election loss and graceful stop working.
+	fatalErrCh := make(chan error, 1)
+
+	if mysqlFramework != nil {
+		go func() {
+			fatalErrCh <- processEvents(ctx, logger, mysqlInformer, mysqlFramework, "mysqlconfigs")
+		}()
+	}
+
+	go func() {
+		fatalErrCh <- processEvents(ctx, logger, informer, operatorFramework, "postgresqlconfigs")
+	}()
+
+	err = <-fatalErrCh
+	if err != nil {
+		return err
+	}
+
+	// processEvents returns nil when the context is cancelled. The deferred
+	// Close calls tear down the database pools.
+	logger.Log("info", "shutting down: context cancelled")
+	return nil
+}
+
+// maxInformerErrors is the number of informer errors in a row, without a
+// single event delivered in between, considered a persistent failure taking
+// the operator down.
+const maxInformerErrors = 10
+
+// processEvents streams informer events into the operator framework. An
+// informer error doesn't take the operator down, it is counted and logged and
+// the watch is recreated with backoff. Only persistent failure returns an
+// error.
+func processEvents(ctx context.Context, logger micrologger.Logger, informer *operatorkitinformer.Informer, operatorFramework *operatorkitframework.Framework, resource string) error {
+	// The framework consumes proxy channels living for the whole run, so
+	// the watch can be recreated without restarting event processing. The
+	// error channel is owned by this function, the framework never sees
+	// informer errors.
+	deleteChan := make(chan watch.Event)
+	updateChan := make(chan watch.Event)
+	errChan := make(chan error)
+	go operatorFramework.ProcessEvents(ctx, deleteChan, updateChan, errChan)
+
+	retryDelay := time.Second
+	consecutiveErrors := 0
 
 	for {
-		select {
-		case event := <-deleteChan:
-			onDeleteFunc(event.Object)
-		case event := <-updateChan:
-			onUpdateFunc(event.Object)
-		case err := <-errChan:
-			return fmt.Errorf("reconciling: informer error: %s", err)
+		informerDeleteChan, informerUpdateChan, informerErrChan := informer.Watch(ctx)
+
+		watching := true
+		for watching {
+			select {
+			case <-ctx.Done():
+				return nil
+			case e := <-informerDeleteChan:
+				deleteChan <- e
+				retryDelay = time.Second
+				consecutiveErrors = 0
+			case e := <-informerUpdateChan:
+				updateChan <- e
+				retryDelay = time.Second
+				consecutiveErrors = 0
+			case err := <-informerErrChan:
+				informerErrorTotal.WithLabelValues(resource).Inc()
+				consecutiveErrors++
+				if consecutiveErrors >= maxInformerErrors {
+					return fmt.Errorf("reconciling: informer error: %s", err)
+				}
+				logger.Log("error", fmt.Sprintf("informer error, recreating watch: %s", err), "resource", resource, "delay", retryDelay)
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(retryDelay):
+				}
+				retryDelay *= 2
+				if retryDelay > time.Minute {
+					retryDelay = time.Minute
+				}
+				watching = false
+			}
 		}
 	}
 }
@@ -0,0 +1,656 @@
+// Package solution4 is solution2 rebuilt on top of the typed clientset in
+// pkg/client. The raw REST paths and hand-rolled decoders are replaced by
+// typed List/Watch calls.
+package solution4
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/giantswarm/operator-workshop/backup"
+	"github.com/giantswarm/operator-workshop/crdutil"
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/eventrecorder"
+	"github.com/giantswarm/operator-workshop/logging"
+	"github.com/giantswarm/operator-workshop/mysqlops"
+	"github.com/giantswarm/operator-workshop/pkg/healthz"
+	"github.com/giantswarm/operator-workshop/postgresqlops"
+	"github.com/giantswarm/operator-workshop/serverref"
+	"github.com/giantswarm/operator-workshop/tracing"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	clientset "github.com/giantswarm/operator-workshop/pkg/client"
+	externalversions "github.com/giantswarm/operator-workshop/pkg/client/informers/externalversions"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Config struct {
+	DBHost     string
+	DBPort     int
+	DBUser     string
+	DBPassword string
+	// DBPasswordFile is a file holding the password, e.g. a mounted
+	// Secret volume. It takes precedence over DBPassword.
+	DBPasswordFile string
+
+	// DryRun logs mutating actions, SQL statements and Kubernetes
+	// writes, instead of performing them.
+	DryRun bool
+
+	// MySQLHost enables the MySQLConfig reconciler when set. The workshop
+	// can run against Postgres only.
+	MySQLHost     string
+	MySQLPort     int
+	MySQLUser     string
+	MySQLPassword string
+
+	// NamespacePrefix derives actual database names as
+	// <namespace>_<spec.database> preventing cross-namespace collisions.
+	NamespacePrefix bool
+
+	// Namespace restricts the operator to objects in one namespace. Empty
+	// watches all namespaces.
+	Namespace string
+	// LabelSelector restricts the operator to objects matching the
+	// selector.
+	LabelSelector string
+
+	// HealthAddr is the address the liveness and readiness probe
+	// endpoints are served on. Empty disables the endpoints.
+	HealthAddr string
+
+	// K8sQPS limits queries per second against the Kubernetes API. Zero
+	// uses the client default.
+	K8sQPS float64
+	// K8sBurst is the maximum burst of queries against the Kubernetes
+	// API. Zero uses the client default.
+	K8sBurst int
+
+	K8sInCluster bool
+	K8sServer    string
+	K8sCrtFile   string
+	K8sKeyFile   string
+	K8sCAFile    string
+}
+
+func Run(ctx context.Context, config Config) error {
+	restConfig, err := newBaseRestConfig(config)
+	if err != nil {
+		return fmt.Errorf("creating REST config: %s", err)
+	}
+
+	k8sExtClient, err := apiextensionsclient.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating K8s client: %s", err)
+	}
+
+	k8sClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("creating containerconf clientset: %s", err)
+	}
+
+	// Create Custom Resource Definitions.
+	crds := []*apiextensionsv1beta1.CustomResourceDefinition{
+		crdutil.NewCRD("postgresqlconfigs", "postgresqlconfig", "PostgreSQLConfig"),
+		crdutil.NewCRD("postgresqlservers", "postgresqlserver", "PostgreSQLServer"),
+		crdutil.NewCRD("mysqlconfigs", "mysqlconfig", "MySQLConfig"),
+	}
+	for _, crd := range crds {
+		if config.DryRun {
+			logging.Infof("dry-run: would create custom resource name=%s", crd.Name)
+			continue
+		}
+
+		logging.Infof("creating custom resource name=%s", crd.Name)
+
+		_, err := k8sExtClient.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
+		if apierrors.IsAlreadyExists(err) {
+			logging.Infof("creating custom resource name=%s: already exists", crd.Name)
+		} else if err != nil {
+			return fmt.Errorf("creating custom resource name=%s: %s", crd.Name, err)
+		} else {
+			logging.Infof("creating custom resource name=%s: created", crd.Name)
+		}
+
+		// Install kubectl get columns. Best effort, apiservers predating
+		// additionalPrinterColumns drop them.
+		columns := crdutil.ConfigColumns
+		if crd.Spec.Names.Kind == "PostgreSQLServer" {
+			columns = crdutil.ServerColumns
+		}
+		err = crdutil.EnsurePrinterColumns(k8sExtClient.Apiextensions().RESTClient(), crd.Name, columns)
+		if err != nil {
+			logging.Warnf("installing printer columns name=%s: %s", crd.Name, err)
+		}
+	}
+
+	// Wait for the Custom Resources to be ready. The typed clients return
+	// errors until the apiserver serves the endpoints.
+	{
+		attempt := 1
+		maxAttempts := 10
+		checkInterval := time.Millisecond * 200
+
+		for ; ; attempt++ {
+			logging.Infof("checking custom resource readiness attempt=%d", attempt)
+
+			_, pgErr := k8sClient.ContainerconfV1().PostgreSQLConfigs("").List(apismetav1.ListOptions{})
+			_, serverErr := k8sClient.ContainerconfV1().PostgreSQLServers("").List(apismetav1.ListOptions{})
+			_, mysqlErr := k8sClient.ContainerconfV1().MySQLConfigs("").List(apismetav1.ListOptions{})
+			err := pgErr
+			if err == nil {
+				err = serverErr
+			}
+			if err == nil {
+				err = mysqlErr
+			}
+
+			if err != nil && attempt == maxAttempts {
+				return fmt.Errorf("checking custom resource readiness attempt=%d: %s", attempt, err)
+			} else if err != nil {
+				logging.Infof("checking custom resource readiness attempt=%d: not ready yet", attempt)
+				time.Sleep(checkInterval)
+			} else {
+				logging.Infof("checking custom resource readiness attempt=%d: ready", attempt)
+				break
+			}
+		}
+	}
+
+	// Create PostgreSQLOps.
+	var ops *postgresqlops.PostgreSQLOps
+	{
+		config := postgresqlops.Config{
+			Host:         config.DBHost,
+			Port:         config.DBPort,
+			User:         config.DBUser,
+			Password:     config.DBPassword,
+			PasswordFile: config.DBPasswordFile,
+			DryRun:       config.DryRun,
+		}
+
+		ops, err = postgresqlops.New(config)
+		if err != nil {
+			return fmt.Errorf("creating PostgreSQLOps: %s", err)
+		}
+
+		defer ops.Close()
+
+		err = ops.CheckCompatibility()
+		if err != nil {
+			return fmt.Errorf("checking server compatibility: %s", err)
+		}
+	}
+
+	// Serve liveness and readiness probes when an address is configured.
+	// Readiness covers the Kubernetes API and the database server.
+	if config.HealthAddr != "" {
+		s := healthz.New(healthz.Config{
+			Address: config.HealthAddr,
+			Checks: map[string]healthz.Check{
+				"kubernetes": func(ctx context.Context) error {
+					_, err := k8sExtClient.Apiextensions().RESTClient().Get().AbsPath("/version").DoRaw()
+					return err
+				},
+				"postgresql": func(ctx context.Context) error {
+					return ops.Ping(ctx)
+				},
+			},
+		})
+		go func() {
+			err := s.Run()
+			if err != nil {
+				logging.Errorf("serving health endpoints addr=%#q: %s", config.HealthAddr, err)
+			}
+		}()
+	}
+
+	// Create a resource instance providing reconciliation methods. The
+	// resolver lets objects target other Postgres servers via
+	// Spec.ServerRef.
+	var resource *customobject.Resource
+	{
+		resolver := serverref.NewResolver(k8sExtClient.Apiextensions().RESTClient())
+		resource = customobject.NewResourceWithResolver(ops, resolver)
+		resource.SetSecretReader(serverref.NewSecretReader(k8sExtClient.Apiextensions().RESTClient()))
+		resource.SetSecretWriter(serverref.NewSecretWriter(k8sExtClient.Apiextensions().RESTClient()))
+		resource.SetConfigMapReader(serverref.NewConfigMapReader(k8sExtClient.Apiextensions().RESTClient()))
+	}
+
+	// Create a server registry collecting connections of reconciled
+	// PostgreSQLServer objects, so PostgreSQLConfig objects can reference
+	// a server by name.
+	var registry *customobject.ServerRegistry
+	{
+		registry = customobject.NewServerRegistry()
+		resource.SetServerRegistry(registry)
+	}
+
+	// Create an event recorder making reconciliation outcomes visible via
+	// kubectl describe.
+	var recorder *eventrecorder.Recorder
+	{
+		c := eventrecorder.Config{
+			RESTClient: k8sExtClient.Apiextensions().RESTClient(),
+			Component:  "operator-workshop-solution4",
+			DryRun:     config.DryRun,
+		}
+		recorder = eventrecorder.New(c)
+	}
+
+	// updateStatus writes back the object status. No-op updates are
+	// skipped so informer resyncs don't produce update loops.
+	updateStatus := func(orig *containerconfv1.PostgreSQLConfig, mutate func(*customobject.PostgreSQLConfigStatus)) {
+		updated := orig.DeepCopy()
+		mutate(&updated.Status)
+		updated.Status.ObservedGeneration = orig.Generation
+
+		if reflect.DeepEqual(orig.Status, updated.Status) {
+			return
+		}
+
+		if config.DryRun {
+			logging.Infof("dry-run: would update status namespace=%s name=%s", orig.Namespace, orig.Name)
+			return
+		}
+
+		_, err := k8sClient.ContainerconfV1().PostgreSQLConfigs(orig.Namespace).Update(updated)
+		if err != nil {
+			logging.Errorf("reconciling: updating status obj=%#v: %s", orig.PostgreSQLConfig, err)
+		}
+	}
+
+	// Create the backup controller performing scheduled backups declared
+	// in spec.backup.
+	var backupController *backup.Controller
+	{
+		c := backup.Config{
+			ListConfigs: func() ([]containerconfv1.PostgreSQLConfig, error) {
+				list, err := k8sClient.ContainerconfV1().PostgreSQLConfigs(config.Namespace).List(apismetav1.ListOptions{LabelSelector: config.LabelSelector})
+				if err != nil {
+					return nil, err
+				}
+				return list.Items, nil
+			},
+			ReadSecret: serverref.NewSecretReader(k8sExtClient.Apiextensions().RESTClient()),
+			Resource:   resource,
+			RecordBackup: func(obj *containerconfv1.PostgreSQLConfig, t time.Time) {
+				updateStatus(obj, func(s *customobject.PostgreSQLConfigStatus) {
+					s.LastBackupTime = t.Format(time.RFC3339)
+				})
+			},
+		}
+		backupController = backup.New(c)
+	}
+
+	// Create reconciliation events handler functions.
+
+	onUpdateFunc := func(obj interface{}) {
+		postgreSQLConfig, ok := obj.(*containerconfv1.PostgreSQLConfig)
+		if !ok {
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+		}
+		if customobject.IsPaused(postgreSQLConfig.Annotations) {
+			logging.Debugf("reconciling: paused obj=%#v", postgreSQLConfig.PostgreSQLConfig)
+			updateStatus(postgreSQLConfig, func(s *customobject.PostgreSQLConfigStatus) {
+				s.Phase = "Paused"
+				s.SetCondition(customobject.ConditionPaused, customobject.ConditionTrue, "PausedAnnotation", "reconciliation suspended")
+			})
+			return
+		}
+		spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+		reconcileSpan.SetAttribute("solution", "solution4")
+		reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+		reconcileSpan.SetAttribute("event", "update")
+		reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+		reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+		_, validateSpan := tracing.Start(spanCtx, "validate")
+		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+		validateSpan.End(err)
+		if err != nil {
+			logging.Errorf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+			reconcileSpan.End(err)
+			validationErr := err
+			updateStatus(postgreSQLConfig, func(s *customobject.PostgreSQLConfigStatus) {
+				s.Phase = "Invalid"
+				s.SetCondition(customobject.ConditionInvalidSpec, customobject.ConditionTrue, "ValidationFailed", validationErr.Error())
+				s.SetCondition(customobject.ConditionReady, customobject.ConditionFalse, "ValidationFailed", validationErr.Error())
+			})
+			return
+		}
+
+		co := &postgreSQLConfig.PostgreSQLConfig
+		if config.NamespacePrefix {
+			co = customobject.NamespacePrefixed(co, postgreSQLConfig.Namespace)
+		}
+
+		_, ensureSpan := tracing.Start(spanCtx, "ensure")
+		status, err := resource.EnsureCreated(co)
+		ensureSpan.End(err)
+		reconcileSpan.End(err)
+		if err != nil {
+			logging.Errorf("reconciling: error: processing update obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+			updateStatus(postgreSQLConfig, func(s *customobject.PostgreSQLConfigStatus) {
+				s.Phase = "Degraded"
+				s.RecordReconcile("error", err.Error())
+				s.SetCondition(customobject.ConditionReady, customobject.ConditionFalse, "ReconcileFailed", err.Error())
+				s.SetCondition(customobject.ConditionDegraded, customobject.ConditionTrue, "ReconcileFailed", err.Error())
+			})
+		} else {
+			logging.Infof("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+			var rotatedRevision string
+			revision := customobject.RotationRevision(postgreSQLConfig.Annotations)
+			if revision != "" && revision != postgreSQLConfig.Status.RotatedRevision {
+				if config.DryRun {
+					logging.Infof("dry-run: would rotate user passwords namespace=%s name=%s revision=%s", postgreSQLConfig.Namespace, postgreSQLConfig.Name, revision)
+				} else {
+					_, rotateSpan := tracing.Start(spanCtx, "rotate")
+					err := resource.RotateUserPasswords(spanCtx, co)
+					rotateSpan.End(err)
+					if err != nil {
+						logging.Errorf("reconciling: rotating user passwords obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+						recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "RotationFailed", "rotating user passwords: %s", err)
+					} else {
+						recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "PasswordsRotated", "rotated user passwords, revision %s", revision)
+						rotatedRevision = revision
+					}
+				}
+			}
+			connection, err := resource.Connection(co)
+			if err != nil {
+				logging.Errorf("reconciling: resolving connection obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			}
+			var applied []string
+			if co.Spec.MigrationsRef != nil {
+				applied, err = resource.AppliedMigrations(co)
+				if err != nil {
+					logging.Errorf("reconciling: listing applied migrations obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+				}
+			}
+			updateStatus(postgreSQLConfig, func(s *customobject.PostgreSQLConfigStatus) {
+				s.DatabaseName = co.Spec.Database
+				if connection != nil {
+					s.Connection = connection
+				}
+				if applied != nil {
+					s.AppliedMigrations = applied
+				}
+				if rotatedRevision != "" {
+					s.RotatedRevision = rotatedRevision
+				}
+				s.Phase = "Ready"
+				s.RecordReconcile(status, "")
+				s.SetCondition(customobject.ConditionReady, customobject.ConditionTrue, "Reconciled", status)
+				s.SetCondition(customobject.ConditionOwnerSynced, customobject.ConditionTrue, "Reconciled", "")
+				s.SetCondition(customobject.ConditionDegraded, customobject.ConditionFalse, "Reconciled", "")
+			})
+		}
+	}
+
+	onDeleteFunc := func(obj interface{}) {
+		postgreSQLConfig, ok := obj.(*containerconfv1.PostgreSQLConfig)
+		if !ok {
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, postgreSQLConfig)
+		}
+		if customobject.IsPaused(postgreSQLConfig.Annotations) {
+			logging.Debugf("reconciling: paused obj=%#v", postgreSQLConfig.PostgreSQLConfig)
+			return
+		}
+		spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+		reconcileSpan.SetAttribute("solution", "solution4")
+		reconcileSpan.SetAttribute("resource", "postgresqlconfigs")
+		reconcileSpan.SetAttribute("event", "delete")
+		reconcileSpan.SetAttribute("namespace", postgreSQLConfig.Namespace)
+		reconcileSpan.SetAttribute("name", postgreSQLConfig.Name)
+
+		_, validateSpan := tracing.Start(spanCtx, "validate")
+		err := customobject.Validate(postgreSQLConfig.PostgreSQLConfig)
+		validateSpan.End(err)
+		if err != nil {
+			logging.Errorf("reconciling: error invalid obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+		}
+
+		co := &postgreSQLConfig.PostgreSQLConfig
+		if config.NamespacePrefix {
+			co = customobject.NamespacePrefixed(co, postgreSQLConfig.Namespace)
+		}
+
+		_, ensureSpan := tracing.Start(spanCtx, "ensure")
+		status, err := resource.EnsureDeleted(co)
+		ensureSpan.End(err)
+		reconcileSpan.End(err)
+		if err != nil {
+			logging.Errorf("reconciling: error: processing delete obj=%#v: %s", postgreSQLConfig.PostgreSQLConfig, err)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+		} else {
+			logging.Infof("reconciling: reconciled: %s obj=%#v", status, postgreSQLConfig.PostgreSQLConfig)
+			recorder.Eventf("PostgreSQLConfig", postgreSQLConfig.Namespace, postgreSQLConfig.Name, "Normal", "Reconciled", "%s", status)
+		}
+	}
+
+	// Create PostgreSQLServer reconciliation events handler functions. The
+	// resulting database resource is a registered connection, not a
+	// database.
+
+	onServerUpdateFunc := func(obj interface{}) {
+		server, ok := obj.(*containerconfv1.PostgreSQLServer)
+		if !ok {
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, server)
+		}
+		err := customobject.ValidatePostgreSQLServer(server.PostgreSQLServer)
+		if err != nil {
+			logging.Errorf("reconciling: error invalid obj=%#v: %s", server.PostgreSQLServer, err)
+			recorder.Eventf("PostgreSQLServer", server.Namespace, server.Name, "Warning", "ReconcileFailed", "validating object: %s", err)
+			return
+		}
+
+		serverOps, err := serverref.NewServerOps(k8sExtClient.Apiextensions().RESTClient(), server.Spec)
+		if err != nil {
+			logging.Errorf("reconciling: error: processing update obj=%#v: %s", server.PostgreSQLServer, err)
+			recorder.Eventf("PostgreSQLServer", server.Namespace, server.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+			return
+		}
+
+		registry.Register(server.Name, serverOps)
+		logging.Infof("reconciling: reconciled: server registered obj=%#v", server.PostgreSQLServer)
+		recorder.Eventf("PostgreSQLServer", server.Namespace, server.Name, "Normal", "Reconciled", "server registered")
+	}
+
+	onServerDeleteFunc := func(obj interface{}) {
+		server, ok := obj.(*containerconfv1.PostgreSQLServer)
+		if !ok {
+			logging.Warnf("reconciling: wrong type %T, want %T", obj, server)
+		}
+
+		registry.Deregister(server.Name)
+		logging.Infof("reconciling: reconciled: server deregistered obj=%#v", server.PostgreSQLServer)
+		recorder.Eventf("PostgreSQLServer", server.Namespace, server.Name, "Normal", "Reconciled", "server deregistered")
+	}
+
+	// Start reconciliation loop.
+
+	// In Giant Swarm we believe that you should treat Added and Updated as
+	// the same thing. Otherwise you most likely don't write a correct
+	// reconciliation.
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { onUpdateFunc(obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { onUpdateFunc(newObj) },
+		DeleteFunc: func(obj interface{}) { onDeleteFunc(obj) },
+	}
+
+	// The shared informer factory scopes all informers to the configured
+	// namespace and label selector. One informer per resource type is
+	// shared between all callers.
+	factory := externalversions.NewFilteredSharedInformerFactory(k8sClient, time.Second*15, config.Namespace, func(options *apismetav1.ListOptions) {
+		options.LabelSelector = config.LabelSelector
+	})
+
+	factory.Containerconf().V1().PostgreSQLConfigs().Informer().AddEventHandler(handler)
+
+	serverHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { onServerUpdateFunc(obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { onServerUpdateFunc(newObj) },
+		DeleteFunc: func(obj interface{}) { onServerDeleteFunc(obj) },
+	}
+
+	factory.Containerconf().V1().PostgreSQLServers().Informer().AddEventHandler(serverHandler)
+
+	// Create the MySQL reconciliation when a MySQL server is configured.
+	// The workshop can run against Postgres only.
+	if config.MySQLHost != "" {
+		var mysqlOps *mysqlops.MySQLOps
+		{
+			c := mysqlops.Config{
+				Host:     config.MySQLHost,
+				Port:     config.MySQLPort,
+				User:     config.MySQLUser,
+				Password: config.MySQLPassword,
+				DryRun:   config.DryRun,
+			}
+
+			mysqlOps, err = mysqlops.New(c)
+			if err != nil {
+				return fmt.Errorf("creating MySQLOps: %s", err)
+			}
+
+			defer mysqlOps.Close()
+		}
+
+		mysqlResource := customobject.NewMySQLResource(mysqlOps)
+
+		onMySQLUpdateFunc := func(obj interface{}) {
+			mySQLConfig, ok := obj.(*containerconfv1.MySQLConfig)
+			if !ok {
+				logging.Warnf("reconciling: wrong type %T, want %T", obj, mySQLConfig)
+			}
+			if customobject.IsPaused(mySQLConfig.Annotations) {
+				logging.Debugf("reconciling: paused obj=%#v", mySQLConfig.MySQLConfig)
+				return
+			}
+			spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+			reconcileSpan.SetAttribute("solution", "solution4")
+			reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+			reconcileSpan.SetAttribute("event", "update")
+			reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+			reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+			_, validateSpan := tracing.Start(spanCtx, "validate")
+			err := customobject.ValidateMySQL(mySQLConfig.MySQLConfig)
+			validateSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error invalid obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "InvalidSpec", "%s", err)
+				reconcileSpan.End(err)
+				return
+			}
+
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := mysqlResource.EnsureCreated(&mySQLConfig.MySQLConfig)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error: processing update obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing update: %s", err)
+			} else {
+				logging.Infof("reconciling: reconciled: %s obj=%#v", status, mySQLConfig.MySQLConfig)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+			}
+		}
+
+		onMySQLDeleteFunc := func(obj interface{}) {
+			mySQLConfig, ok := obj.(*containerconfv1.MySQLConfig)
+			if !ok {
+				logging.Warnf("reconciling: wrong type %T, want %T", obj, mySQLConfig)
+			}
+			if customobject.IsPaused(mySQLConfig.Annotations) {
+				logging.Debugf("reconciling: paused obj=%#v", mySQLConfig.MySQLConfig)
+				return
+			}
+			spanCtx, reconcileSpan := tracing.Start(context.Background(), "reconcile")
+			reconcileSpan.SetAttribute("solution", "solution4")
+			reconcileSpan.SetAttribute("resource", "mysqlconfigs")
+			reconcileSpan.SetAttribute("event", "delete")
+			reconcileSpan.SetAttribute("namespace", mySQLConfig.Namespace)
+			reconcileSpan.SetAttribute("name", mySQLConfig.Name)
+
+			_, validateSpan := tracing.Start(spanCtx, "validate")
+			err := customobject.ValidateMySQL(mySQLConfig.MySQLConfig)
+			validateSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error invalid obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+			}
+
+			_, ensureSpan := tracing.Start(spanCtx, "ensure")
+			status, err := mysqlResource.EnsureDeleted(&mySQLConfig.MySQLConfig)
+			ensureSpan.End(err)
+			reconcileSpan.End(err)
+			if err != nil {
+				logging.Errorf("reconciling: error: processing delete obj=%#v: %s", mySQLConfig.MySQLConfig, err)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Warning", "ReconcileFailed", "processing delete: %s", err)
+			} else {
+				logging.Infof("reconciling: reconciled: %s obj=%#v", status, mySQLConfig.MySQLConfig)
+				recorder.Eventf("MySQLConfig", mySQLConfig.Namespace, mySQLConfig.Name, "Normal", "Reconciled", "%s", status)
+			}
+		}
+
+		mysqlHandler := cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { onMySQLUpdateFunc(obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { onMySQLUpdateFunc(newObj) },
+			DeleteFunc: func(obj interface{}) { onMySQLDeleteFunc(obj) },
+		}
+
+		factory.Containerconf().V1().MySQLConfigs().Informer().AddEventHandler(mysqlHandler)
+	}
+
+	go backupController.Run(ctx)
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func newBaseRestConfig(config Config) (*rest.Config, error) {
+	var restConfig *rest.Config
+
+	if config.K8sInCluster {
+		var err error
+		restConfig, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("creating incluster config: %s", err)
+		}
+	} else {
+		restConfig = &rest.Config{
+			Host: config.K8sServer,
+			TLSClientConfig: rest.TLSClientConfig{
+				CertFile: config.K8sCrtFile,
+				KeyFile:  config.K8sKeyFile,
+				CAFile:   config.K8sCAFile,
+			},
+		}
+	}
+
+	// Rate limit API calls protecting small API servers from tight
+	// reconcile loops.
+	if config.K8sQPS > 0 {
+		restConfig.QPS = float32(config.K8sQPS)
+	}
+	if config.K8sBurst > 0 {
+		restConfig.Burst = config.K8sBurst
+	}
+
+	return restConfig, nil
+}
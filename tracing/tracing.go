@@ -0,0 +1,238 @@
+// Package tracing records spans around reconciliations and exports them to
+// an OpenTelemetry collector using the OTLP/HTTP JSON encoding, so a single
+// object's reconciliation can be inspected in Jaeger. The encoding is small
+// enough to hand-roll, keeping the dependency footprint at zero. Spans
+// parent through the context, call sites use the package level Start.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config is the tracer configuration.
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector base URL, e.g.
+	// "http://localhost:4318". Empty disables tracing.
+	Endpoint string
+	// ServiceName identifies the operator in the trace backend.
+	ServiceName string
+	// FlushInterval is how often buffered spans are exported. Defaults to
+	// 5 seconds.
+	FlushInterval time.Duration
+}
+
+// Span is one timed operation. End must be called exactly once.
+type Span struct {
+	tracer     *tracer
+	traceID    string
+	spanID     string
+	parentID   string
+	name       string
+	start      time.Time
+	attributes []attribute
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+type tracer struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+
+	mutex sync.Mutex
+	spans []finishedSpan
+}
+
+type finishedSpan struct {
+	traceID    string
+	spanID     string
+	parentID   string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes []attribute
+	err        error
+}
+
+type contextKey struct{}
+
+// std is the default tracer. It is nil until Configure enables tracing, all
+// operations on a nil tracer are no-ops.
+var std *tracer
+
+// Configure enables span export to the configured endpoint, typically right
+// after flag parsing. An empty endpoint leaves tracing disabled.
+func Configure(config Config) {
+	if config.Endpoint == "" {
+		return
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+
+	std = &tracer{
+		endpoint:    config.Endpoint,
+		serviceName: config.ServiceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+
+	go func() {
+		for {
+			time.Sleep(config.FlushInterval)
+			std.flush()
+		}
+	}()
+}
+
+// Start begins a span. When the context carries a span the new one becomes
+// its child, otherwise it starts a new trace. The returned context carries
+// the new span and is passed to nested operations.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	if std == nil {
+		return ctx, nil
+	}
+
+	s := &Span{
+		tracer: std,
+		spanID: randomHex(8),
+		name:   name,
+		start:  time.Now(),
+	}
+	if parent, ok := ctx.Value(contextKey{}).(*Span); ok && parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+
+	return context.WithValue(ctx, contextKey{}, s), s
+}
+
+// SetAttribute attaches a key/value pair to the span, e.g. the namespace and
+// name of the reconciled object.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.attributes = append(s.attributes, attribute{key: key, value: value})
+}
+
+// End finishes the span. A non-nil error marks the span failed and is
+// recorded as its status message.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	f := finishedSpan{
+		traceID:    s.traceID,
+		spanID:     s.spanID,
+		parentID:   s.parentID,
+		name:       s.name,
+		start:      s.start,
+		end:        time.Now(),
+		attributes: s.attributes,
+		err:        err,
+	}
+
+	s.tracer.mutex.Lock()
+	s.tracer.spans = append(s.tracer.spans, f)
+	s.tracer.mutex.Unlock()
+}
+
+// flush exports the buffered spans. Export failures are dropped silently,
+// tracing must never affect reconciliation.
+func (t *tracer) flush() {
+	t.mutex.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mutex.Unlock()
+
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(exportRequest(t.serviceName, spans))
+	if err != nil {
+		return
+	}
+
+	resp, err := t.client.Post(t.endpoint+"/v1/traces", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// exportRequest builds the OTLP ExportTraceServiceRequest JSON structure.
+func exportRequest(serviceName string, spans []finishedSpan) map[string]interface{} {
+	otlpSpans := make([]map[string]interface{}, 0, len(spans))
+	for _, s := range spans {
+		attrs := make([]map[string]interface{}, 0, len(s.attributes))
+		for _, a := range s.attributes {
+			attrs = append(attrs, map[string]interface{}{
+				"key":   a.key,
+				"value": map[string]interface{}{"stringValue": a.value},
+			})
+		}
+
+		span := map[string]interface{}{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": fmt.Sprintf("%d", s.start.UnixNano()),
+			"endTimeUnixNano":   fmt.Sprintf("%d", s.end.UnixNano()),
+			"attributes":        attrs,
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]interface{}{
+				"code":    2, // STATUS_CODE_ERROR
+				"message": s.err.Error(),
+			}
+		}
+
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{
+			{
+				"resource": map[string]interface{}{
+					"attributes": []map[string]interface{}{
+						{
+							"key":   "service.name",
+							"value": map[string]interface{}{"stringValue": serviceName},
+						},
+					},
+				},
+				"scopeSpans": []map[string]interface{}{
+					{
+						"scope": map[string]interface{}{"name": "operator-workshop"},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+// Package watchhealth tracks watch stream health. Stream recreations and
+// the establishment time of the current stream are exported as Prometheus
+// metrics, and a readiness signal reports unhealthy when no watch stream
+// was alive for too long.
+package watchhealth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	streamTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "watch",
+			Name:      "stream_total",
+			Help:      "Number of watch streams established by resource.",
+		},
+		[]string{"resource"},
+	)
+	streamEstablished = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "operator_workshop",
+			Subsystem: "watch",
+			Name:      "stream_established_timestamp_seconds",
+			Help:      "Unix time the current watch stream was established by resource. The age of the stream is time() minus this value.",
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(streamTotal)
+	prometheus.MustRegister(streamEstablished)
+}
+
+// Tracker tracks the watch stream health of one resource.
+type Tracker struct {
+	resource       string
+	unhealthyAfter time.Duration
+
+	mutex     sync.Mutex
+	active    bool
+	lastAlive time.Time
+}
+
+// NewTracker creates a tracker reporting unhealthy when no watch stream
+// was alive for unhealthyAfter. Zero defaults to 5 minutes.
+func NewTracker(resource string, unhealthyAfter time.Duration) *Tracker {
+	if unhealthyAfter == 0 {
+		unhealthyAfter = time.Minute * 5
+	}
+
+	return &Tracker{
+		resource:       resource,
+		unhealthyAfter: unhealthyAfter,
+		lastAlive:      time.Now(),
+	}
+}
+
+// StreamEstablished records a new watch stream.
+func (t *Tracker) StreamEstablished() {
+	streamTotal.WithLabelValues(t.resource).Inc()
+	streamEstablished.WithLabelValues(t.resource).Set(float64(time.Now().Unix()))
+
+	t.mutex.Lock()
+	t.active = true
+	t.lastAlive = time.Now()
+	t.mutex.Unlock()
+}
+
+// StreamBroken records the end of the current watch stream.
+func (t *Tracker) StreamBroken() {
+	t.mutex.Lock()
+	t.active = false
+	t.lastAlive = time.Now()
+	t.mutex.Unlock()
+}
+
+// Healthy reports whether a watch stream is alive, or was alive recently
+// enough. It is meant to back a readiness probe.
+func (t *Tracker) Healthy() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.active {
+		return true
+	}
+	return time.Since(t.lastAlive) < t.unhealthyAfter
+}
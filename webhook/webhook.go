@@ -0,0 +1,251 @@
+// Package webhook implements a validating admission webhook rejecting
+// invalid or conflicting PostgreSQLConfig objects before they are stored,
+// instead of the operator discovering the problem during reconciliation.
+//
+// It targets the 1.7 GenericAdmissionWebhook alpha admission controller, so
+// the configuration object is ExternalAdmissionHookConfiguration and the
+// review payload is the admission.k8s.io/v1alpha1 AdmissionReview. The
+// review types are not vendored, the minimal subset used here is declared
+// locally.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/giantswarm/operator-workshop/customobject"
+	"github.com/giantswarm/operator-workshop/logging"
+	"k8s.io/client-go/rest"
+
+	containerconfv1 "github.com/giantswarm/operator-workshop/pkg/apis/containerconf/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apismetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	admissionregistrationv1alpha1 "k8s.io/client-go/pkg/apis/admissionregistration/v1alpha1"
+)
+
+// AdmissionReview is the admission.k8s.io/v1alpha1 review payload subset
+// used by the webhook.
+type AdmissionReview struct {
+	apismetav1.TypeMeta `json:",inline"`
+
+	Spec   AdmissionReviewSpec   `json:"spec,omitempty"`
+	Status AdmissionReviewStatus `json:"status,omitempty"`
+}
+
+// AdmissionReviewSpec describes the object under review.
+type AdmissionReviewSpec struct {
+	// Object is the reviewed object serialized as sent to the apiserver.
+	Object json.RawMessage `json:"object,omitempty"`
+	// OldObject is the existing object on updates.
+	OldObject json.RawMessage `json:"oldObject,omitempty"`
+	// Operation is CREATE, UPDATE or DELETE.
+	Operation string `json:"operation,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AdmissionReviewStatus is the review verdict.
+type AdmissionReviewStatus struct {
+	Allowed bool               `json:"allowed"`
+	Result  *apismetav1.Status `json:"result,omitempty"`
+}
+
+// Config is the webhook server configuration.
+type Config struct {
+	// Address is the TLS listen address, e.g. ":8443".
+	Address string
+	// CertFile and KeyFile are the serving certificate. The apiserver
+	// validates it against the CABundle registered with the hook
+	// configuration.
+	CertFile string
+	KeyFile  string
+
+	// ListConfigs returns the existing PostgreSQLConfig objects. It is
+	// used to reject two objects claiming the same database name.
+	ListConfigs func() ([]containerconfv1.PostgreSQLConfig, error)
+}
+
+// Server is the validating admission webhook server.
+type Server struct {
+	address     string
+	certFile    string
+	keyFile     string
+	listConfigs func() ([]containerconfv1.PostgreSQLConfig, error)
+}
+
+// New creates a webhook server.
+func New(config Config) *Server {
+	return &Server{
+		address:     config.Address,
+		certFile:    config.CertFile,
+		keyFile:     config.KeyFile,
+		listConfigs: config.ListConfigs,
+	}
+}
+
+// Run serves the webhook until the listener fails. It blocks.
+func (s *Server) Run() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admit", s.handleAdmit)
+
+	err := http.ListenAndServeTLS(s.address, s.certFile, s.keyFile, mux)
+	if err != nil {
+		return fmt.Errorf("serving webhook: %s", err)
+	}
+
+	return nil
+}
+
+func (s *Server) handleAdmit(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var review AdmissionReview
+	err = json.Unmarshal(body, &review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decoding review: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	review.Status = s.review(review.Spec)
+
+	res, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding review: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res)
+}
+
+// review validates the object under review. Deletes are always allowed.
+func (s *Server) review(spec AdmissionReviewSpec) AdmissionReviewStatus {
+	if spec.Operation == "DELETE" {
+		return allowed()
+	}
+
+	var obj containerconfv1.PostgreSQLConfig
+	err := json.Unmarshal(spec.Object, &obj)
+	if err != nil {
+		return denied(fmt.Sprintf("decoding object: %s", err))
+	}
+
+	err = customobject.Validate(obj.PostgreSQLConfig)
+	if err != nil {
+		return denied(fmt.Sprintf("validating object: %s", err))
+	}
+
+	// spec.database is immutable. Changing it silently leaks the old
+	// database, so a change is only accepted as an explicit rename, i.e.
+	// when renameFrom declares the previous name.
+	if spec.Operation == "UPDATE" && len(spec.OldObject) > 0 {
+		var old containerconfv1.PostgreSQLConfig
+		err := json.Unmarshal(spec.OldObject, &old)
+		if err != nil {
+			return denied(fmt.Sprintf("decoding old object: %s", err))
+		}
+
+		if old.Spec.Database != obj.Spec.Database && obj.Spec.RenameFrom != old.Spec.Database {
+			return denied(fmt.Sprintf("spec.database is immutable: changing database=%#q requires renameFrom=%#q declaring the migration", old.Spec.Database, old.Spec.Database))
+		}
+	}
+
+	// Reject objects claiming a database name already claimed by another
+	// object.
+	if s.listConfigs != nil {
+		existing, err := s.listConfigs()
+		if err != nil {
+			logging.Errorf("reviewing object: listing existing objects: %s", err)
+			return denied(fmt.Sprintf("listing existing objects: %s", err))
+		}
+
+		for _, e := range existing {
+			if e.Namespace == obj.Namespace && e.Name == obj.Name {
+				// The object under review itself, i.e. an update.
+				continue
+			}
+			if e.Spec.Database == obj.Spec.Database {
+				return denied(fmt.Sprintf("database=%#q already claimed by %s/%s", obj.Spec.Database, e.Namespace, e.Name))
+			}
+		}
+	}
+
+	return allowed()
+}
+
+func allowed() AdmissionReviewStatus {
+	return AdmissionReviewStatus{Allowed: true}
+}
+
+func denied(message string) AdmissionReviewStatus {
+	return AdmissionReviewStatus{
+		Allowed: false,
+		Result: &apismetav1.Status{
+			Message: message,
+		},
+	}
+}
+
+// RegisterConfiguration creates the ExternalAdmissionHookConfiguration
+// pointing the apiserver at the webhook service. It is posted raw the same
+// way the solutions bootstrap their CRDs.
+func RegisterConfiguration(restClient rest.Interface, serviceNamespace, serviceName string, caBundle []byte) error {
+	failurePolicy := admissionregistrationv1alpha1.Fail
+
+	configuration := admissionregistrationv1alpha1.ExternalAdmissionHookConfiguration{
+		TypeMeta: apismetav1.TypeMeta{
+			APIVersion: "admissionregistration.k8s.io/v1alpha1",
+			Kind:       "ExternalAdmissionHookConfiguration",
+		},
+		ObjectMeta: apismetav1.ObjectMeta{
+			Name: "postgresqlconfigs.containerconf.de",
+		},
+		ExternalAdmissionHooks: []admissionregistrationv1alpha1.ExternalAdmissionHook{
+			{
+				Name: "postgresqlconfigs.containerconf.de",
+				ClientConfig: admissionregistrationv1alpha1.AdmissionHookClientConfig{
+					Service: admissionregistrationv1alpha1.ServiceReference{
+						Namespace: serviceNamespace,
+						Name:      serviceName,
+					},
+					CABundle: caBundle,
+				},
+				Rules: []admissionregistrationv1alpha1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1alpha1.OperationType{
+							admissionregistrationv1alpha1.Create,
+							admissionregistrationv1alpha1.Update,
+						},
+						Rule: admissionregistrationv1alpha1.Rule{
+							APIGroups:   []string{"containerconf.de"},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"postgresqlconfigs"},
+						},
+					},
+				},
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+
+	body, err := json.Marshal(configuration)
+	if err != nil {
+		return fmt.Errorf("marshalling hook configuration: %s", err)
+	}
+
+	endpoint := "/apis/admissionregistration.k8s.io/v1alpha1/externaladmissionhookconfigurations"
+	err = restClient.Post().AbsPath(endpoint).Body(body).Do().Error()
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("creating hook configuration: %s", err)
+	}
+
+	return nil
+}